@@ -21,6 +21,8 @@ import (
 	"time"
 
 	crawler "github.com/michealroberts/koroutine-web-crawler/pkg/crawler"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/scope"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -28,6 +30,178 @@ import (
 
 /*****************************************************************************************************************/
 
+// crawlerOptions builds the crawler options from the optional query parameters of a crawl request.
+func crawlerOptions(c *gin.Context) ([]crawler.Option, error) {
+	var opts []crawler.Option
+
+	if concurrency := c.Query("concurrency"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid concurrency parameter")
+		}
+
+		opts = append(opts, crawler.WithConcurrency(n))
+	}
+
+	if maxPages := c.Query("max_pages"); maxPages != "" {
+		n, err := strconv.Atoi(maxPages)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_pages parameter")
+		}
+
+		opts = append(opts, crawler.WithMaxPages(n))
+	}
+
+	if maxPagesPerHost := c.Query("max_pages_per_host"); maxPagesPerHost != "" {
+		n, err := strconv.Atoi(maxPagesPerHost)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_pages_per_host parameter")
+		}
+
+		opts = append(opts, crawler.WithMaxPagesPerHost(n))
+	}
+
+	if maxBytes := c.Query("max_bytes"); maxBytes != "" {
+		n, err := strconv.ParseInt(maxBytes, 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_bytes parameter")
+		}
+
+		opts = append(opts, crawler.WithMaxBytes(n))
+	}
+
+	if maxBodySize := c.Query("max_body_size"); maxBodySize != "" {
+		n, err := strconv.ParseInt(maxBodySize, 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_body_size parameter")
+		}
+
+		opts = append(opts, crawler.WithMaxBodySize(n))
+	}
+
+	// The duration is given as a Go duration, e.g., ?max_duration=30s:
+	if maxDuration := c.Query("max_duration"); maxDuration != "" {
+		d, err := time.ParseDuration(maxDuration)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_duration parameter")
+		}
+
+		opts = append(opts, crawler.WithMaxDuration(d))
+	}
+
+	policy := limit.DefaultPolicy
+
+	if rate := c.Query("rate"); rate != "" {
+		f, err := strconv.ParseFloat(rate, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate parameter")
+		}
+
+		policy.RequestsPerSecond = f
+	}
+
+	if burst := c.Query("burst"); burst != "" {
+		n, err := strconv.Atoi(burst)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid burst parameter")
+		}
+
+		policy.Burst = n
+	}
+
+	adaptive := limit.DefaultAdaptivePolicy
+
+	if hostConcurrency := c.Query("host_concurrency"); hostConcurrency != "" {
+		n, err := strconv.Atoi(hostConcurrency)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid host_concurrency parameter")
+		}
+
+		policy.MaxConcurrent = n
+
+		// Only an explicit host concurrency caps an adaptive limit, as the default would leave it no room to grow:
+		if n > 0 {
+			adaptive.MaxConcurrent = max(n, adaptive.MinConcurrent)
+		}
+	}
+
+	if c.Query("adaptive") == "true" {
+		policy.Adaptive = &adaptive
+	}
+
+	opts = append(opts, crawler.WithHostPolicy(policy))
+
+	if maxAttempts := c.Query("max_attempts"); maxAttempts != "" {
+		n, err := strconv.Atoi(maxAttempts)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_attempts parameter")
+		}
+
+		retry := crawler.DefaultRetryPolicy
+		retry.MaxAttempts = n
+
+		opts = append(opts, crawler.WithRetryPolicy(retry))
+	}
+
+	if maxRedirects := c.Query("max_redirects"); maxRedirects != "" {
+		n, err := strconv.Atoi(maxRedirects)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_redirects parameter")
+		}
+
+		opts = append(opts, crawler.WithMaxRedirects(n))
+	}
+
+	if c.Query("offscope_redirects") == "true" {
+		opts = append(opts, crawler.WithOffScopeRedirects())
+	}
+
+	if c.Query("ignore_robots") == "true" {
+		opts = append(opts, crawler.WithoutRobots())
+	}
+
+	if c.Query("ignore_nofollow") == "true" {
+		opts = append(opts, crawler.WithoutNoFollow())
+	}
+
+	if c.Query("sitemaps") == "true" {
+		opts = append(opts, crawler.WithSitemaps())
+	}
+
+	if c.Query("check_resources") == "true" {
+		opts = append(opts, crawler.WithResourceChecks())
+	}
+
+	if userAgent := c.Query("user_agent"); userAgent != "" {
+		opts = append(opts, crawler.WithUserAgent(userAgent))
+	}
+
+	// Scope parameters which take several values are repeated, e.g., ?exclude=*.pdf&exclude=*.zip:
+	opts = append(opts, crawler.WithScope(scope.Rules{
+		AllowedHosts:      c.QueryArray("allow_hosts"),
+		IncludeSubdomains: c.Query("subdomains") == "true",
+		SameSite:          c.Query("same_site") == "true",
+		PathPrefixes:      c.QueryArray("path_prefix"),
+		Include:           c.QueryArray("include"),
+		Exclude:           c.QueryArray("exclude"),
+	}))
+
+	return opts, nil
+}
+
+/*****************************************************************************************************************/
+
 func setupRouter() *gin.Engine {
 	// A new gin base router:
 	router := gin.Default()
@@ -54,6 +228,21 @@ func setupRouter() *gin.Engine {
 			return
 		}
 
+		opts, err := crawlerOptions(c)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Create the crawler before committing to the stream, so configuration errors can be reported:
+		crawler, err := crawler.New(opts...)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		// Setup headers for SSE
 		c.Writer.Header().Set("Content-Type", "text/event-stream")
 		c.Writer.Header().Set("Cache-Control", "no-cache")
@@ -62,10 +251,8 @@ func setupRouter() *gin.Engine {
 		// Ensure that the Gin does not buffer the responses
 		c.Writer.Flush()
 
-		// Start the crawler in a new goroutine
-		crawler := crawler.New()
-
-		go crawler.Crawl(domain, maxDepth)
+		// Start the crawler in a new goroutine, bound to the lifetime of the request:
+		go crawler.CrawlContext(c.Request.Context(), domain, maxDepth)
 
 		// Create a ticker for keep-alive messages
 		ticker := time.NewTicker(30 * time.Second)
@@ -74,14 +261,18 @@ func setupRouter() *gin.Engine {
 
 		done := c.Request.Context().Done()
 
+		stream := crawler.Stream()
+
 		for {
 			select {
-			case _, ok := <-crawler.Stream():
+			case _, ok := <-stream:
 				if !ok {
-					// Close the stream if channel is closed
-					return
+					// The stream is closed once the crawl is complete, which is left to send the final events:
+					stream = nil
+					continue
 				}
-				jsonNode, err := json.Marshal(crawler.Root)
+				// The tree is still being written by the crawler's workers, so it is encoded under its lock:
+				jsonNode, err := crawler.MarshalTree()
 
 				if err != nil {
 					log.Printf("Failed to marshal node: %v", err)
@@ -101,7 +292,30 @@ func setupRouter() *gin.Engine {
 				// Flush the response
 				c.Writer.Flush()
 			case <-crawler.Complete():
-				// End the request when the crawler is done
+				// Nodes may still be buffered in the stream, so the complete tree is sent once more:
+				if jsonTree, err := crawler.MarshalTree(); err == nil {
+					c.Writer.WriteString(fmt.Sprintf("data: %s\n\n", string(jsonTree)))
+					c.Writer.Flush()
+				}
+
+				// Then the link graph as a final event, ending the request when the crawler is done
+				if jsonGraph, err := json.Marshal(crawler.Graph); err == nil {
+					c.Writer.WriteString(fmt.Sprintf("event: graph\ndata: %s\n\n", string(jsonGraph)))
+					c.Writer.Flush()
+				}
+
+				// Followed by the structured data types found across the crawl:
+				if jsonTypes, err := json.Marshal(crawler.StructuredDataTypes()); err == nil {
+					c.Writer.WriteString(fmt.Sprintf("event: structured_data\ndata: %s\n\n", string(jsonTypes)))
+					c.Writer.Flush()
+				}
+
+				// And the final stats of the crawl, e.g., the concurrency each host was throttled to:
+				if jsonStats, err := json.Marshal(crawler.Stats()); err == nil {
+					c.Writer.WriteString(fmt.Sprintf("event: stats\ndata: %s\n\n", string(jsonStats)))
+					c.Writer.Flush()
+				}
+
 				log.Println("Crawler has completed")
 				return
 			case <-done:
@@ -9,22 +9,95 @@ package main
 /*****************************************************************************************************************/
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/michealroberts/koroutine-web-crawler/pkg/crawler"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/scope"
 	"github.com/xlab/treeprint"
 )
 
 /*****************************************************************************************************************/
 
+// stringList is a flag which may be given more than once, collecting every value, e.g., -exclude a -exclude b.
+type stringList []string
+
+/*****************************************************************************************************************/
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+/*****************************************************************************************************************/
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// label describes a node for the tree output, e.g., "https://example.com [200 text/html 42ms "Example"]".
+func label(node *crawler.URLNode) string {
+	label := node.URL
+
+	if node.Source != "" {
+		label = fmt.Sprintf("%s [%s]", label, node.Source)
+	}
+
+	if node.StatusCode != 0 {
+		label = fmt.Sprintf("%s [%d %s %dms", label, node.StatusCode, node.ContentType, node.ResponseTimeMs)
+
+		if node.Title != "" {
+			label = fmt.Sprintf("%s %q", label, node.Title)
+		}
+
+		label += "]"
+	}
+
+	if node.Truncated {
+		label += " (truncated)"
+	}
+
+	if node.NoIndex {
+		label += " (noindex)"
+	}
+
+	if node.Skipped != "" {
+		label = fmt.Sprintf("%s (skipped: %s)", label, node.Skipped)
+	}
+
+	if node.Error != "" {
+		label = fmt.Sprintf("%s (error: %s)", label, node.Error)
+	}
+
+	if len(node.Redirects) > 0 {
+		label = fmt.Sprintf("%s (%d redirects to %s)", label, len(node.Redirects), node.FinalURL)
+	}
+
+	if node.Attempts > 1 {
+		label = fmt.Sprintf("%s (%d attempts)", label, node.Attempts)
+	}
+
+	return label
+}
+
+/*****************************************************************************************************************/
+
 func addNodes(branch treeprint.Tree, node *crawler.URLNode) {
 	if node == nil {
 		return
 	}
 
-	nodeBranch := branch.AddBranch(node.URL)
+	nodeBranch := branch.AddBranch(label(node))
 
 	for _, child := range node.Links {
 		addNodes(nodeBranch, child)
@@ -40,6 +113,58 @@ func main() {
 
 	depth := flag.Int("depth", 3, "The maximum depth to crawl")
 
+	concurrency := flag.Int("concurrency", 0, "The number of workers fetching pages at once (0 for the default)")
+
+	maxPages := flag.Int("max-pages", 0, "The maximum number of pages to fetch (0 for no limit)")
+
+	maxPagesPerHost := flag.Int("max-pages-per-host", 0, "The maximum number of pages to fetch from a single host (0 for no limit)")
+
+	maxBodySize := flag.Int64("max-body-size", crawler.DefaultMaxBodySize, "The number of bytes of a page read before it is truncated")
+
+	maxBytes := flag.Int64("max-bytes", 0, "The maximum number of bytes to download (0 for no limit)")
+
+	rate := flag.Float64("rate", limit.DefaultPolicy.RequestsPerSecond, "The maximum requests per second per host (0 for no limit)")
+
+	burst := flag.Int("burst", limit.DefaultPolicy.Burst, "The number of back-to-back requests allowed per host")
+
+	hostConcurrency := flag.Int("host-concurrency", limit.DefaultPolicy.MaxConcurrent, "The maximum concurrent requests per host (0 for no limit)")
+
+	adaptive := flag.Bool("adaptive", false, "Adjust the concurrency of every host to its latency and error rate, up to -host-concurrency if set")
+
+	maxRedirects := flag.Int("max-redirects", crawler.DefaultMaxRedirects, "The number of redirects followed for a single page (0 to return redirects as they are)")
+
+	offScopeRedirects := flag.Bool("offscope-redirects", false, "Follow redirects to URLs out of scope, e.g., to a login page on another host")
+
+	maxAttempts := flag.Int("max-attempts", crawler.DefaultRetryPolicy.MaxAttempts, "The number of times a page failing with a transient error is fetched (1 for no retries)")
+
+	retryDelay := flag.Duration("retry-delay", crawler.DefaultRetryPolicy.BaseDelay, "The backoff before the first retry, doubling for every retry after it")
+
+	ignoreRobots := flag.Bool("ignore-robots", false, "Ignore robots.txt rules, e.g., for sites we own")
+
+	ignoreNoFollow := flag.Bool("ignore-nofollow", false, "Follow links despite rel=nofollow and nofollow directives")
+
+	sitemaps := flag.Bool("sitemaps", false, "Seed the crawl with the URLs listed in the site's sitemaps")
+
+	checkResources := flag.Bool("check-resources", false, "Check the images, scripts and stylesheets of every page with a HEAD request")
+
+	userAgent := flag.String("user-agent", crawler.DefaultUserAgent, "The User-Agent header sent with every request")
+
+	timeout := flag.Duration("timeout", 0, "The maximum duration of the crawl (0 for no limit)")
+
+	var allowHosts, pathPrefixes, include, exclude stringList
+
+	flag.Var(&allowHosts, "allow-hosts", "A host in scope besides the start host (repeatable)")
+
+	subdomains := flag.Bool("subdomains", false, "Include subdomains of the start host and allowed hosts")
+
+	sameSite := flag.Bool("same-site", false, "Include every host sharing the start host's registrable domain")
+
+	flag.Var(&pathPrefixes, "path-prefix", "Only follow links within this path, e.g., /docs/ (repeatable)")
+
+	flag.Var(&include, "include", "Only follow URLs matching this glob, or containing a match of this re: pattern (repeatable)")
+
+	flag.Var(&exclude, "exclude", "Never follow URLs matching this glob, or containing a match of this re: pattern (repeatable)")
+
 	flag.Parse()
 
 	if *domain == "" {
@@ -56,8 +181,82 @@ func main() {
 
 	fmt.Println("Crawling depth:", *depth)
 
+	// Cancel the crawl on interrupt, printing whatever has been crawled so far:
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	defer stop()
+
+	policy := limit.Policy{
+		RequestsPerSecond: *rate,
+		Burst:             *burst,
+		MaxConcurrent:     *hostConcurrency,
+	}
+
+	if *adaptive {
+		adaptivePolicy := limit.DefaultAdaptivePolicy
+
+		// The default host concurrency is that of a fixed limit, which would leave the controller no room to grow:
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "host-concurrency" && *hostConcurrency > 0 {
+				adaptivePolicy.MaxConcurrent = max(*hostConcurrency, adaptivePolicy.MinConcurrent)
+			}
+		})
+
+		policy.Adaptive = &adaptivePolicy
+	}
+
+	opts := []crawler.Option{
+		crawler.WithConcurrency(*concurrency),
+		crawler.WithMaxPages(*maxPages),
+		crawler.WithMaxPagesPerHost(*maxPagesPerHost),
+		crawler.WithMaxBytes(*maxBytes),
+		crawler.WithMaxBodySize(*maxBodySize),
+		crawler.WithMaxDuration(*timeout),
+		crawler.WithUserAgent(*userAgent),
+		crawler.WithHostPolicy(policy),
+		crawler.WithMaxRedirects(*maxRedirects),
+		crawler.WithRetryPolicy(crawler.RetryPolicy{
+			MaxAttempts: *maxAttempts,
+			BaseDelay:   *retryDelay,
+			MaxDelay:    max(*retryDelay, crawler.DefaultRetryPolicy.MaxDelay),
+		}),
+		crawler.WithScope(scope.Rules{
+			AllowedHosts:      allowHosts,
+			IncludeSubdomains: *subdomains,
+			SameSite:          *sameSite,
+			PathPrefixes:      pathPrefixes,
+			Include:           include,
+			Exclude:           exclude,
+		}),
+	}
+
+	if *ignoreRobots {
+		opts = append(opts, crawler.WithoutRobots())
+	}
+
+	if *ignoreNoFollow {
+		opts = append(opts, crawler.WithoutNoFollow())
+	}
+
+	if *offScopeRedirects {
+		opts = append(opts, crawler.WithOffScopeRedirects())
+	}
+
+	if *sitemaps {
+		opts = append(opts, crawler.WithSitemaps())
+	}
+
+	if *checkResources {
+		opts = append(opts, crawler.WithResourceChecks())
+	}
+
 	// Create a new crawler instance:
-	crawler := crawler.New()
+	crawler, err := crawler.New(opts...)
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	// Start a goroutine to print the streaming results
 	go func() {
@@ -69,13 +268,18 @@ func main() {
 	// Start timing
 	start := time.Now()
 
-	rootNode, err := crawler.Crawl(*domain, *depth)
+	rootNode, err := crawler.CrawlContext(ctx, *domain, *depth)
 
-	if err != nil {
+	// A cancelled crawl, or one stopped by a budget, still returns the partial tree:
+	if err != nil && !errors.Is(err, context.Canceled) && crawler.Stats().StopReason == "" {
 		fmt.Println(err)
 		return
 	}
 
+	if err != nil {
+		fmt.Println("Crawling stopped early:", err)
+	}
+
 	// End timing
 	elapsed := time.Since(start)
 
@@ -88,6 +292,26 @@ func main() {
 	addNodes(tree, rootNode)
 
 	fmt.Println(tree.String())
+
+	if redirects := crawler.Stats().Redirects; redirects.Pages > 0 {
+		fmt.Printf("Redirects: %d pages, %d hops, %d loops, %d too long, %d out of scope\n",
+			redirects.Pages, redirects.Hops, redirects.Loops, redirects.TooMany, redirects.OutOfScope)
+	}
+
+	fmt.Println("Hosts:")
+
+	for _, host := range crawler.Stats().Hosts {
+		fmt.Printf("  %s: %d requests, limit %d, %dms latency, %.0f%% errors, %d throttled\n",
+			host.Host, host.Requests, host.Limit, host.LatencyMs, host.ErrorRate*100, host.Throttled)
+	}
+
+	if types := crawler.StructuredDataTypes(); len(types) > 0 {
+		fmt.Println("Structured data types:")
+
+		for _, t := range types {
+			fmt.Printf("  %s: %d pages\n", t.Type, t.Pages)
+		}
+	}
 }
 
 /*****************************************************************************************************************/
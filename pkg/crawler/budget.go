@@ -0,0 +1,122 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*****************************************************************************************************************/
+
+// The budgets of a crawl, reported as the StopReason of its stats once one has stopped it, and as the Skipped
+// reason of the pages it prevented from being fetched.
+const (
+	BudgetMaxPages        = "max pages"
+	BudgetMaxBytes        = "max bytes"
+	BudgetMaxDuration     = "max duration"
+	BudgetMaxPagesPerHost = "max pages per host"
+)
+
+/*****************************************************************************************************************/
+
+// ErrBudgetExceeded is returned alongside the partially crawled tree when a crawl was stopped by one of its
+// budgets, e.g., "budget exceeded: max bytes".
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+/*****************************************************************************************************************/
+
+// reservePage counts a page of a host against the page budgets, reporting the budget which prevents it from
+// being fetched, or an empty string if it may be. Hitting a crawl-wide budget stops the crawl, while hitting the
+// budget of a host only skips that host's pages.
+func (c *Crawler) reservePage(host string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	host = strings.ToLower(host)
+
+	switch {
+	case c.stopReason != "":
+		return c.stopReason
+	case c.config.MaxPages > 0 && c.pages >= c.config.MaxPages:
+		c.stopLocked(BudgetMaxPages)
+		return BudgetMaxPages
+	case c.config.MaxBytes > 0 && c.bytes >= c.config.MaxBytes:
+		c.stopLocked(BudgetMaxBytes)
+		return BudgetMaxBytes
+	case c.config.MaxPagesPerHost > 0 && c.hostPages[host] >= c.config.MaxPagesPerHost:
+		return BudgetMaxPagesPerHost
+	}
+
+	c.pages++
+	c.hostPages[host]++
+
+	return ""
+}
+
+/*****************************************************************************************************************/
+
+// spendBytes counts the bytes downloaded for a page against the byte budget, stopping the crawl once it has been
+// spent.
+func (c *Crawler) spendBytes(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bytes += n
+
+	if c.config.MaxBytes > 0 && c.bytes >= c.config.MaxBytes {
+		c.stopLocked(BudgetMaxBytes)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// stopLocked winds the crawl down because a budget was hit: no further pages are scheduled, and those which were
+// queued are skipped with the budget as their reason, while those already being fetched are completed. Only the
+// first budget hit is reported. Callers hold c.mu.
+func (c *Crawler) stopLocked(budget string) {
+	if c.stopReason != "" {
+		return
+	}
+
+	c.stopReason = budget
+
+	close(c.halt)
+
+	for _, t := range c.frontier.close() {
+		t.node.Skipped = budget
+	}
+}
+
+/*****************************************************************************************************************/
+
+// stopped reports whether a budget has stopped the crawl.
+func (c *Crawler) stopped() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stopReason != ""
+}
+
+/*****************************************************************************************************************/
+
+// budgetError returns the error reported when the crawl was stopped by a budget, or nil if it was not.
+func (c *Crawler) budgetError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopReason == "" {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrBudgetExceeded, c.stopReason)
+}
+
+/*****************************************************************************************************************/
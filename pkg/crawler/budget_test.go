@@ -0,0 +1,196 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/scope"
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+func TestCrawlMaxBytes(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	home := `<a href="/a">A</a><a href="/b">B</a>` + strings.Repeat(" ", 100)
+
+	httpmock.RegisterResponder("GET", baseURL, htmlPage(home))
+
+	httpmock.RegisterResponder("GET", baseURL+"/a", htmlPage(`<a href="/c">C</a>`+strings.Repeat(" ", 100)))
+
+	httpmock.RegisterResponder("GET", baseURL+"/b", htmlPage(`<p>B</p>`))
+
+	// The budget is spent by the first two pages, so the third is never fetched:
+	c, err := New(WithHostPolicy(limit.Policy{}), WithConcurrency(1), WithMaxBytes(200))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 2)
+
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.EqualError(t, err, "budget exceeded: max bytes")
+	assert.Len(t, root.Links, 2)
+	assert.Equal(t, 200, root.Links[0].StatusCode)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET "+baseURL+"/b"])
+
+	// Pages queued when the budget was hit, and links found after it, report it as the reason they were skipped:
+	assert.Equal(t, BudgetMaxBytes, root.Links[1].Skipped)
+	assert.Len(t, root.Links[0].Links, 1)
+	assert.Equal(t, BudgetMaxBytes, root.Links[0].Links[0].Skipped)
+
+	stats := c.Stats()
+	assert.Equal(t, BudgetMaxBytes, stats.StopReason)
+	assert.Equal(t, 2, stats.Pages)
+	assert.GreaterOrEqual(t, stats.Bytes, int64(200))
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlMaxBytesCountsBytesRead(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	home := `<a href="/report.pdf">Report</a><a href="/archive">Archive</a>`
+
+	httpmock.RegisterResponder("GET", baseURL, htmlPage(home))
+
+	// Responses declaring far more than they send, which are never read in full:
+	declare := func(contentType string, length int64) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "%PDF-1.7")
+			resp.Header.Add("Content-Type", contentType)
+			resp.ContentLength = length
+			return resp, nil
+		}
+	}
+
+	httpmock.RegisterResponder("GET", baseURL+"/report.pdf", declare("application/pdf", 8<<20))
+
+	httpmock.RegisterResponder("GET", baseURL+"/archive", declare("text/html", 5<<30))
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithMaxBytes(1<<20))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 2)
+	assert.Contains(t, root.Links[0].Error, "unsupported content type")
+	assert.Contains(t, root.Links[1].Error, "body too large")
+
+	// Only the home page was downloaded:
+	stats := c.Stats()
+	assert.Empty(t, stats.StopReason)
+	assert.Equal(t, int64(len(home)), stats.Bytes)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlMaxPagesPerHost(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		htmlPage(`<a href="/a">A</a><a href="/b">B</a><a href="https://docs.koroutine.tech/">Docs</a>`))
+
+	httpmock.RegisterResponder("GET", baseURL+"/a", htmlPage(`<p>A</p>`))
+
+	httpmock.RegisterResponder("GET", "https://docs.koroutine.tech/", htmlPage(`<p>Docs</p>`))
+
+	c, err := New(
+		WithHostPolicy(limit.Policy{}),
+		WithConcurrency(1),
+		WithScope(scope.Rules{IncludeSubdomains: true}),
+		WithMaxPagesPerHost(2),
+	)
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	// A host budget skips the host's remaining pages, without stopping the crawl of other hosts:
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 3)
+	assert.Equal(t, 200, root.Links[0].StatusCode)
+	assert.Equal(t, BudgetMaxPagesPerHost, root.Links[1].Skipped)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET "+baseURL+"/b"])
+	assert.Equal(t, 200, root.Links[2].StatusCode)
+	assert.Empty(t, c.Stats().StopReason)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlMaxDuration(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL, htmlPage(`<a href="/slow">Slow</a>`))
+
+	// The slow page blocks until the request context is done:
+	httpmock.RegisterResponder("GET", baseURL+"/slow",
+		func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithMaxDuration(100*time.Millisecond))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	root, err := c.Crawl(baseURL, 2)
+
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.EqualError(t, err, "budget exceeded: max duration")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 200, root.StatusCode)
+	assert.Len(t, root.Links, 1)
+	assert.Equal(t, BudgetMaxDuration, c.Stats().StopReason)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlContextCancelledWithinBudget(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithMaxDuration(time.Minute))
+	assert.NoError(t, err)
+
+	// The caller's deadline is reported as such, rather than as a budget:
+	_, err = c.CrawlContext(ctx, baseURL, 1)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrBudgetExceeded)
+	assert.Empty(t, c.Stats().StopReason)
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,499 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	parse "github.com/michealroberts/koroutine-web-crawler/pkg/parsers"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/scope"
+	validate "github.com/michealroberts/koroutine-web-crawler/pkg/validators"
+)
+
+/*****************************************************************************************************************/
+
+const (
+	// DefaultTimeout is the per-request timeout of the HTTP client created when no client is provided.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultStreamBufferSize is the number of nodes buffered on the stream channel before sends block.
+	DefaultStreamBufferSize = 100
+
+	// DefaultConcurrency is the number of workers fetching pages at once.
+	DefaultConcurrency = 8
+
+	// DefaultMaxBodySize is the number of bytes of a response body read before it is truncated, 10 MiB.
+	DefaultMaxBodySize = 10 << 20
+
+	// DefaultUserAgent is the User-Agent header sent with every request.
+	DefaultUserAgent = "koroutine-web-crawler/1.0"
+)
+
+/*****************************************************************************************************************/
+
+// ErrInvalidConfig is returned by New when the provided options cannot be combined into a valid configuration.
+var ErrInvalidConfig = errors.New("invalid crawler config")
+
+/*****************************************************************************************************************/
+
+// Config holds the tunable settings of a Crawler. Zero values are replaced with the package defaults.
+type Config struct {
+	// Client is the HTTP client used for all requests. When nil, a client with Timeout is created.
+	Client *http.Client
+	// Timeout is the per-request timeout of the created client. It cannot be combined with Client.
+	Timeout time.Duration
+	// StreamBufferSize is the capacity of the channel returned by Stream.
+	StreamBufferSize int
+	// UserAgent is sent as the User-Agent header of every request.
+	UserAgent string
+	// Scope configures which discovered URLs are in scope of the crawl, see scope.Rules for the evaluation order.
+	Scope scope.Rules
+	// Concurrency is the number of workers fetching pages at once, i.e., the global connection limit.
+	Concurrency int
+	// MaxPages is the maximum number of pages fetched during a crawl (0 for no limit).
+	MaxPages int
+	// MaxPagesPerHost is the maximum number of pages fetched from a single host (0 for no limit).
+	MaxPagesPerHost int
+	// MaxBytes is the maximum number of bytes of page and sitemap bodies downloaded during a crawl (0 for no limit).
+	MaxBytes int64
+	// MaxDuration is the maximum wall-clock duration of a crawl (0 for no limit).
+	MaxDuration time.Duration
+	// HostPolicy is the rate and concurrency limit applied to every host. When nil, limit.DefaultPolicy is used.
+	HostPolicy *limit.Policy
+	// Retry is the policy for retrying pages failing with a transient error. When nil, DefaultRetryPolicy is used.
+	Retry *RetryPolicy
+	// HostRules override the host policy for hosts matching their pattern; the first matching rule wins.
+	HostRules []limit.Rule
+	// IgnoreRobots disables robots.txt handling for every host.
+	IgnoreRobots bool
+	// IgnoreRobotsHosts are host patterns, e.g., sites we own, for which robots.txt is not honoured.
+	IgnoreRobotsHosts []string
+	// Canonical selects how URLs are normalised for deduplication and scope checks. When nil,
+	// validate.DefaultCanonicalOptions is used.
+	Canonical *validate.CanonicalOptions
+	// Sitemaps seeds the crawl with the URLs of the sitemaps listed in robots.txt and /sitemap.xml.
+	Sitemaps bool
+	// IgnoreNoFollow follows links despite rel="nofollow" and nofollow meta robots or X-Robots-Tag directives,
+	// which are still recorded on the nodes.
+	IgnoreNoFollow bool
+	// Parsers maps the media types of responses to the parsers extracting their links. When nil,
+	// parse.DefaultRegistry is used, and responses of unregistered media types are not parsed.
+	Parsers *parse.Registry
+	// MaxBodySize is the number of bytes of a response body read before it is truncated, and the largest
+	// Content-Length downloaded at all. When 0, DefaultMaxBodySize is used.
+	MaxBodySize int64
+	// MaxRedirects is the number of redirects followed for a single page, where 0 returns the redirect response
+	// itself. When nil, DefaultMaxRedirects is used.
+	MaxRedirects *int
+	// FollowOffScopeRedirects follows redirects of in-scope pages to URLs out of scope, whose links are still only
+	// followed when in scope.
+	FollowOffScopeRedirects bool
+	// CheckResources checks every in-scope resource of a page, e.g., images and stylesheets, with a HEAD request.
+	CheckResources bool
+}
+
+/*****************************************************************************************************************/
+
+// Option configures a Crawler created with New.
+type Option func(*Config)
+
+/*****************************************************************************************************************/
+
+// WithClient sets the HTTP client used for all requests.
+func WithClient(client *http.Client) Option {
+	return func(cfg *Config) {
+		cfg.Client = client
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithTimeout sets the per-request timeout of the default HTTP client.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Timeout = timeout
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithStreamBufferSize sets the capacity of the channel returned by Stream.
+func WithStreamBufferSize(size int) Option {
+	return func(cfg *Config) {
+		cfg.StreamBufferSize = size
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(cfg *Config) {
+		cfg.UserAgent = userAgent
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithAllowedHosts adds hosts which are in scope in addition to the host of the start URL.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(cfg *Config) {
+		cfg.Scope.AllowedHosts = append(cfg.Scope.AllowedHosts, hosts...)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithScope sets the scope rules, replacing any allowed hosts set before it.
+func WithScope(rules scope.Rules) Option {
+	return func(cfg *Config) {
+		cfg.Scope = rules
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithConcurrency sets the number of workers fetching pages at once.
+func WithConcurrency(n int) Option {
+	return func(cfg *Config) {
+		cfg.Concurrency = n
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithMaxPages sets the maximum number of pages fetched during a crawl.
+func WithMaxPages(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxPages = n
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithMaxPagesPerHost sets the maximum number of pages fetched from a single host, e.g., to sample many sites.
+func WithMaxPagesPerHost(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxPagesPerHost = n
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithMaxBytes sets the maximum number of bytes downloaded during a crawl.
+func WithMaxBytes(n int64) Option {
+	return func(cfg *Config) {
+		cfg.MaxBytes = n
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithMaxDuration sets the maximum wall-clock duration of a crawl.
+func WithMaxDuration(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.MaxDuration = d
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithHostPolicy sets the rate and concurrency limit applied to every host.
+func WithHostPolicy(policy limit.Policy) Option {
+	return func(cfg *Config) {
+		cfg.HostPolicy = &policy
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithRetryPolicy sets the policy for retrying pages failing with a transient error, e.g., RetryPolicy{MaxAttempts: 1}
+// for no retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(cfg *Config) {
+		cfg.Retry = &policy
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithHostRules adds per-host overrides of the host policy, e.g., for sites which can take a faster crawl.
+func WithHostRules(rules ...limit.Rule) Option {
+	return func(cfg *Config) {
+		cfg.HostRules = append(cfg.HostRules, rules...)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithoutRobots disables robots.txt handling for every host.
+func WithoutRobots() Option {
+	return func(cfg *Config) {
+		cfg.IgnoreRobots = true
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithRobotsOverrides disables robots.txt handling for hosts matching any of the patterns, e.g., "*.example.com".
+func WithRobotsOverrides(patterns ...string) Option {
+	return func(cfg *Config) {
+		cfg.IgnoreRobotsHosts = append(cfg.IgnoreRobotsHosts, patterns...)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithCanonicalOptions sets how URLs are normalised for deduplication and scope checks.
+func WithCanonicalOptions(opts validate.CanonicalOptions) Option {
+	return func(cfg *Config) {
+		cfg.Canonical = &opts
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithSitemaps seeds the crawl with the URLs of the sitemaps listed in robots.txt and /sitemap.xml, so pages
+// which nothing links to are crawled too.
+func WithSitemaps() Option {
+	return func(cfg *Config) {
+		cfg.Sitemaps = true
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithoutNoFollow follows links despite rel="nofollow" and nofollow directives, e.g., to audit every link of a
+// site we own. Directives are still recorded on the nodes.
+func WithoutNoFollow() Option {
+	return func(cfg *Config) {
+		cfg.IgnoreNoFollow = true
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithResourceChecks checks every in-scope resource of crawled pages, e.g., images, scripts and stylesheets,
+// with a HEAD request, recording its status on the page's node.
+func WithResourceChecks() Option {
+	return func(cfg *Config) {
+		cfg.CheckResources = true
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithMaxBodySize sets the number of bytes of a response body read before it is truncated, e.g., to skip large
+// files served with an HTML content type.
+func WithMaxBodySize(n int64) Option {
+	return func(cfg *Config) {
+		cfg.MaxBodySize = n
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithMaxRedirects sets the number of redirects followed for a single page, or disables following them with 0.
+func WithMaxRedirects(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxRedirects = &n
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithOffScopeRedirects follows redirects of in-scope pages to URLs out of scope, e.g., to a login page on another
+// host, rather than reporting them with ErrRedirectOutOfScope.
+func WithOffScopeRedirects() Option {
+	return func(cfg *Config) {
+		cfg.FollowOffScopeRedirects = true
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithParsers sets the registry of parsers used for responses, replacing the default parsers.
+func WithParsers(registry *parse.Registry) Option {
+	return func(cfg *Config) {
+		cfg.Parsers = registry
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithParser registers a parser for a media type, e.g., "application/json", alongside the default parsers or any
+// registry set before it. The parser is registered with a copy, so a registry shared with other crawlers is left
+// as it was.
+func WithParser(mediaType string, parser parse.Parser) Option {
+	return func(cfg *Config) {
+		if cfg.Parsers == nil {
+			cfg.Parsers = parse.DefaultRegistry()
+		} else {
+			cfg.Parsers = cfg.Parsers.Clone()
+		}
+
+		cfg.Parsers.Register(mediaType, parser)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// WithConfig replaces the whole configuration, e.g., one loaded from a file.
+func WithConfig(config Config) Option {
+	return func(cfg *Config) {
+		*cfg = config
+	}
+}
+
+/*****************************************************************************************************************/
+
+// Validate reports whether the configuration is usable, before any defaults are applied.
+func (cfg *Config) Validate() error {
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("%w: timeout must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.Client != nil && cfg.Timeout != 0 {
+		return fmt.Errorf("%w: timeout cannot be combined with a custom client, set the client timeout instead", ErrInvalidConfig)
+	}
+
+	if cfg.StreamBufferSize < 0 {
+		return fmt.Errorf("%w: stream buffer size must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.Concurrency < 0 {
+		return fmt.Errorf("%w: concurrency must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.MaxPages < 0 {
+		return fmt.Errorf("%w: max pages must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.MaxPagesPerHost < 0 {
+		return fmt.Errorf("%w: max pages per host must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.MaxBytes < 0 {
+		return fmt.Errorf("%w: max bytes must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.MaxDuration < 0 {
+		return fmt.Errorf("%w: max duration must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.MaxBodySize < 0 {
+		return fmt.Errorf("%w: max body size must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.MaxRedirects != nil && *cfg.MaxRedirects < 0 {
+		return fmt.Errorf("%w: max redirects must not be negative", ErrInvalidConfig)
+	}
+
+	if cfg.HostPolicy != nil {
+		if err := cfg.HostPolicy.Validate(); err != nil {
+			return fmt.Errorf("%w: host policy: %w", ErrInvalidConfig, err)
+		}
+	}
+
+	if cfg.Retry != nil {
+		if err := cfg.Retry.Validate(); err != nil {
+			return fmt.Errorf("%w: retry policy: %w", ErrInvalidConfig, err)
+		}
+	}
+
+	for _, rule := range cfg.HostRules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("%w: host rule: %w", ErrInvalidConfig, err)
+		}
+	}
+
+	for _, pattern := range cfg.IgnoreRobotsHosts {
+		if _, err := path.Match(pattern, ""); pattern == "" || err != nil {
+			return fmt.Errorf("%w: invalid robots override pattern %q", ErrInvalidConfig, pattern)
+		}
+	}
+
+	if cfg.Canonical != nil {
+		for _, pattern := range cfg.Canonical.StripParams {
+			if _, err := path.Match(pattern, ""); pattern == "" || err != nil {
+				return fmt.Errorf("%w: invalid strip parameter pattern %q", ErrInvalidConfig, pattern)
+			}
+		}
+	}
+
+	if err := cfg.Scope.Validate(); err != nil {
+		return fmt.Errorf("%w: scope: %w", ErrInvalidConfig, err)
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// withDefaults returns a copy of the configuration with zero values replaced by the package defaults.
+func (cfg Config) withDefaults() Config {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{
+			Timeout: cfg.Timeout,
+		}
+	}
+
+	if cfg.StreamBufferSize == 0 {
+		cfg.StreamBufferSize = DefaultStreamBufferSize
+	}
+
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+
+	if cfg.HostPolicy == nil {
+		policy := limit.DefaultPolicy
+		cfg.HostPolicy = &policy
+	}
+
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+
+	if cfg.MaxRedirects == nil {
+		maxRedirects := DefaultMaxRedirects
+		cfg.MaxRedirects = &maxRedirects
+	}
+
+	if cfg.Retry == nil {
+		retry := DefaultRetryPolicy
+		cfg.Retry = &retry
+	}
+
+	if cfg.Canonical == nil {
+		canonical := validate.DefaultCanonicalOptions
+		cfg.Canonical = &canonical
+	}
+
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+
+	if cfg.Parsers == nil {
+		cfg.Parsers = parse.DefaultRegistry()
+	}
+
+	return cfg
+}
+
+/*****************************************************************************************************************/
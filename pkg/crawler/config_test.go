@@ -0,0 +1,174 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	parse "github.com/michealroberts/koroutine-web-crawler/pkg/parsers"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/scope"
+	validate "github.com/michealroberts/koroutine-web-crawler/pkg/validators"
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+func TestNewDefaults(t *testing.T) {
+	c, err := New()
+
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultTimeout, c.client.Timeout)
+	assert.Equal(t, DefaultStreamBufferSize, cap(c.stream))
+	assert.Equal(t, DefaultUserAgent, c.config.UserAgent)
+	assert.Equal(t, DefaultConcurrency, c.config.Concurrency)
+	assert.Equal(t, limit.DefaultPolicy, *c.config.HostPolicy)
+	assert.Equal(t, DefaultRetryPolicy, *c.config.Retry)
+	assert.Equal(t, int64(DefaultMaxBodySize), c.config.MaxBodySize)
+	assert.Equal(t, DefaultMaxRedirects, *c.config.MaxRedirects)
+}
+
+/*****************************************************************************************************************/
+
+func TestNewWithOptions(t *testing.T) {
+	client := &http.Client{Timeout: time.Second}
+
+	c, err := New(
+		WithClient(client),
+		WithStreamBufferSize(5),
+		WithUserAgent("test-agent"),
+		WithAllowedHosts("www.koroutine.tech"),
+		WithConcurrency(4),
+		WithMaxPages(10),
+	)
+
+	assert.NoError(t, err)
+	// Redirects are tracked on a copy of the client, leaving the provided client unchanged:
+	assert.Same(t, client, c.config.Client)
+	assert.Equal(t, time.Second, c.client.Timeout)
+	assert.NotNil(t, c.client.CheckRedirect)
+	assert.Nil(t, client.CheckRedirect)
+	assert.Equal(t, 5, cap(c.stream))
+	assert.Equal(t, "test-agent", c.config.UserAgent)
+	assert.Equal(t, []string{"www.koroutine.tech"}, c.config.Scope.AllowedHosts)
+	assert.Equal(t, 4, c.config.Concurrency)
+	assert.Equal(t, 10, c.config.MaxPages)
+}
+
+/*****************************************************************************************************************/
+
+func TestNewWithParserCopiesRegistry(t *testing.T) {
+	shared := parse.DefaultRegistry()
+
+	c, err := New(WithParsers(shared), WithParser("application/json", parse.TextParser))
+
+	assert.NoError(t, err)
+
+	_, ok := c.config.Parsers.Lookup("application/json")
+	assert.True(t, ok)
+
+	// The registry may be shared with other crawlers, so it is never registered with directly:
+	_, ok = shared.Lookup("application/json")
+	assert.False(t, ok)
+}
+
+/*****************************************************************************************************************/
+
+func TestNewInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "Negative timeout",
+			opts: []Option{WithTimeout(-time.Second)},
+		},
+		{
+			name: "Timeout with custom client",
+			opts: []Option{WithClient(&http.Client{}), WithTimeout(time.Second)},
+		},
+		{
+			name: "Negative stream buffer size",
+			opts: []Option{WithStreamBufferSize(-1)},
+		},
+		{
+			name: "Negative concurrency",
+			opts: []Option{WithConcurrency(-1)},
+		},
+		{
+			name: "Negative max pages",
+			opts: []Option{WithMaxPages(-1)},
+		},
+		{
+			name: "Negative host rate",
+			opts: []Option{WithHostPolicy(limit.Policy{RequestsPerSecond: -1})},
+		},
+		{
+			name: "Negative max pages per host",
+			opts: []Option{WithMaxPagesPerHost(-1)},
+		},
+		{
+			name: "Negative max bytes",
+			opts: []Option{WithMaxBytes(-1)},
+		},
+		{
+			name: "Negative max duration",
+			opts: []Option{WithMaxDuration(-time.Second)},
+		},
+		{
+			name: "Negative max body size",
+			opts: []Option{WithMaxBodySize(-1)},
+		},
+		{
+			name: "Negative max redirects",
+			opts: []Option{WithMaxRedirects(-1)},
+		},
+		{
+			name: "Zero retry attempts",
+			opts: []Option{WithRetryPolicy(RetryPolicy{})},
+		},
+		{
+			name: "Retry max delay below base delay",
+			opts: []Option{WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Millisecond})},
+		},
+		{
+			name: "Malformed host rule pattern",
+			opts: []Option{WithHostRules(limit.Rule{Pattern: "[", Policy: limit.DefaultPolicy})},
+		},
+		{
+			name: "Malformed robots override pattern",
+			opts: []Option{WithRobotsOverrides("[")},
+		},
+		{
+			name: "Malformed strip parameter pattern",
+			opts: []Option{WithCanonicalOptions(validate.CanonicalOptions{StripParams: []string{"utm_["}})},
+		},
+		{
+			name: "Empty allowed host",
+			opts: []Option{WithAllowedHosts("")},
+		},
+		{
+			name: "Invalid scope pattern",
+			opts: []Option{WithScope(scope.Rules{Exclude: []string{"re:("}})},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New(tc.opts...)
+
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+			assert.Nil(t, c)
+		})
+	}
+}
+
+/*****************************************************************************************************************/
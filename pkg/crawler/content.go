@@ -0,0 +1,71 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"bufio"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+/*****************************************************************************************************************/
+
+// sniffLength is the number of bytes http.DetectContentType considers.
+const sniffLength = 512
+
+/*****************************************************************************************************************/
+
+// detectContentType returns the Content-Type of a response and its lowercased media type without parameters.
+// When the header is missing or malformed, the content type is sniffed from the start of the body instead.
+func detectContentType(header string, body *bufio.Reader) (string, string) {
+	if header != "" {
+		if mediaType, _, err := mime.ParseMediaType(header); err == nil {
+			return header, strings.ToLower(mediaType)
+		}
+	}
+
+	// Peek returns whatever is available alongside an error for bodies shorter than the sniff length:
+	peek, _ := body.Peek(sniffLength)
+
+	contentType := http.DetectContentType(peek)
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return contentType, mediaType
+}
+
+/*****************************************************************************************************************/
+
+// isXML checks if a media type is XML, e.g., a sitemap or feed, other than XHTML, which is parsed as HTML.
+func isXML(mediaType string) bool {
+	switch {
+	case mediaType == "application/xhtml+xml":
+		return false
+	case mediaType == "application/xml", mediaType == "text/xml":
+		return true
+	default:
+		return strings.HasSuffix(mediaType, "+xml")
+	}
+}
+
+/*****************************************************************************************************************/
+
+// isText checks if a media type is a text document transcoded to UTF-8 before it is parsed, i.e., HTML, XHTML or
+// any other text/* type but XML. Bodies of other media types, e.g., images handled by a custom parser, are parsed
+// as they were received.
+func isText(mediaType string) bool {
+	if mediaType == "application/xhtml+xml" {
+		return true
+	}
+
+	return strings.HasPrefix(mediaType, "text/") && !isXML(mediaType)
+}
+
+/*****************************************************************************************************************/
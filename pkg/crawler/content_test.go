@@ -0,0 +1,122 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		body        string
+		contentType string
+		mediaType   string
+	}{
+		{
+			name:        "Plain HTML",
+			header:      "text/html",
+			contentType: "text/html",
+			mediaType:   "text/html",
+		},
+		{
+			name:        "HTML with charset parameter",
+			header:      "text/html; charset=utf-8",
+			contentType: "text/html; charset=utf-8",
+			mediaType:   "text/html",
+		},
+		{
+			name:        "Uppercase media type",
+			header:      "Text/HTML;Charset=ISO-8859-1",
+			contentType: "Text/HTML;Charset=ISO-8859-1",
+			mediaType:   "text/html",
+		},
+		{
+			name:        "XHTML",
+			header:      "application/xhtml+xml",
+			contentType: "application/xhtml+xml",
+			mediaType:   "application/xhtml+xml",
+		},
+		{
+			name:        "PDF",
+			header:      "application/pdf",
+			contentType: "application/pdf",
+			mediaType:   "application/pdf",
+		},
+		{
+			name:        "Missing header sniffs HTML",
+			body:        "<!DOCTYPE html><html><body></body></html>",
+			contentType: "text/html; charset=utf-8",
+			mediaType:   "text/html",
+		},
+		{
+			name:        "Malformed header sniffs body",
+			header:      "text/html; charset",
+			body:        "%PDF-1.7",
+			contentType: "application/pdf",
+			mediaType:   "application/pdf",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body := bufio.NewReader(strings.NewReader(tc.body))
+
+			contentType, mediaType := detectContentType(tc.header, body)
+
+			assert.Equal(t, tc.contentType, contentType)
+			assert.Equal(t, tc.mediaType, mediaType)
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestIsXML(t *testing.T) {
+	tests := map[string]bool{
+		"application/xml":       true,
+		"text/xml":              true,
+		"application/rss+xml":   true,
+		"application/atom+xml":  true,
+		"application/xhtml+xml": false,
+		"text/html":             false,
+		"text/plain":            false,
+	}
+
+	for mediaType, expected := range tests {
+		assert.Equal(t, expected, isXML(mediaType), mediaType)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestIsText(t *testing.T) {
+	tests := map[string]bool{
+		"text/html":             true,
+		"text/plain":            true,
+		"application/xhtml+xml": true,
+		"text/xml":              false,
+		"application/xml":       false,
+		"application/json":      false,
+		"image/png":             false,
+	}
+
+	for mediaType, expected := range tests {
+		assert.Equal(t, expected, isText(mediaType), mediaType)
+	}
+}
+
+/*****************************************************************************************************************/
@@ -9,50 +9,93 @@ package crawler
 /*****************************************************************************************************************/
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/michealroberts/koroutine-web-crawler/pkg/graph"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
 	parse "github.com/michealroberts/koroutine-web-crawler/pkg/parsers"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/robots"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/scope"
+	validate "github.com/michealroberts/koroutine-web-crawler/pkg/validators"
 )
 
 /*****************************************************************************************************************/
 
-type URLNode struct {
-	URL   string     `json:"url"`
-	Links []*URLNode `json:"links"`
-}
-
-/*****************************************************************************************************************/
-
 type Crawler struct {
 	Root       *URLNode
-	baseDomain string
+	Graph      *graph.LinkGraph // every link between crawled pages, keyed by canonical URL
+	scope      *scope.Scope     // rules deciding which discovered links are followed
 	visited    map[string]bool
+	checked    map[string]*resourceCheck // resources checked so far, by canonical URL
+	types      map[string]int            // number of pages using each structured data type
+	pages      int                       // number of pages fetched so far
+	hostPages  map[string]int            // number of pages fetched so far, by host
+	bytes      int64                     // number of bytes downloaded so far
+	stopReason string                    // the budget which stopped the crawl, if any
+	halt       chan struct{}             // closed once a budget has stopped the crawl
+	redirects  RedirectStats             // redirect chains followed so far
 	mu         sync.Mutex
 	wg         sync.WaitGroup
+	config     Config
 	client     *http.Client
-	stream     chan *URLNode // channel for streaming URL nodes
+	limiter    *limit.HostLimiter // per-host politeness limits enforced on every fetch
+	robots     *robots.Cache      // robots.txt rules of every host seen during the crawl
+	frontier   *frontier          // queue of pages waiting to be fetched by the workers
+	stream     chan *URLNode      // channel for streaming URL nodes
 	done       chan bool
 }
 
 /*****************************************************************************************************************/
 
-// NewCrawler creates a new instance of Crawler with an initialized HTTP client and visited map.
-func New() *Crawler {
+// NewCrawler creates a new instance of Crawler with an initialized HTTP client and visited map. Without any
+// options it uses a 10s request timeout, a 100-slot stream buffer, 8 workers, the polite limit.DefaultPolicy
+// per host and only follows links on the start host.
+func New(opts ...Option) (*Crawler, error) {
+	cfg := Config{}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg = cfg.withDefaults()
+
 	root := &URLNode{} // Initialize with a root node if necessary
 
-	return &Crawler{
-		Root:    root,
-		visited: make(map[string]bool),
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		stream: make(chan *URLNode, 100), // buffered channel to avoid blocking
-		done:   make(chan bool, 1),
+	c := &Crawler{
+		Root:      root,
+		visited:   make(map[string]bool),
+		checked:   make(map[string]*resourceCheck),
+		types:     make(map[string]int),
+		hostPages: make(map[string]int),
+		config:    cfg,
+		limiter:   limit.NewHostLimiter(*cfg.HostPolicy, cfg.HostRules...),
+		stream:    make(chan *URLNode, cfg.StreamBufferSize), // buffered channel to avoid blocking
+		done:      make(chan bool, 1),
+		halt:      make(chan struct{}),
 	}
+
+	// Redirects are tracked on a copy of the client, so the configured client is left as it was provided:
+	client := *cfg.Client
+	client.CheckRedirect = c.checkRedirect(cfg.Client.CheckRedirect)
+	c.client = &client
+
+	// robots.txt files are requested like pages, so they are subject to the same host limits:
+	c.robots = robots.NewCache(limitedClient{crawler: c}, cfg.UserAgent)
+
+	return c, nil
 }
 
 /*****************************************************************************************************************/
@@ -73,6 +116,15 @@ func (c *Crawler) Complete() <-chan bool {
 
 // Crawl starts the crawling process from a given URL up to a maximum depth.
 func (c *Crawler) Crawl(startURL string, maxDepth int) (*URLNode, error) {
+	return c.CrawlContext(context.Background(), startURL, maxDepth)
+}
+
+/*****************************************************************************************************************/
+
+// CrawlContext starts the crawling process from a given URL up to a maximum depth, stopping early if the
+// context is cancelled or its deadline expires, or one of the crawl's budgets is hit. In that case the partially
+// crawled tree is returned along with the context error, or an error wrapping ErrBudgetExceeded.
+func (c *Crawler) CrawlContext(ctx context.Context, startURL string, maxDepth int) (*URLNode, error) {
 	defer close(c.stream) // Ensure the channel is closed when done
 	defer close(c.done)   // Ensure to close the done channel here after Wait
 
@@ -82,95 +134,565 @@ func (c *Crawler) Crawl(startURL string, maxDepth int) (*URLNode, error) {
 		return nil, err
 	}
 
-	c.baseDomain = parsedURL.Host
+	c.scope, err = scope.New(c.canonical(parsedURL), c.config.Scope)
+
+	if err != nil {
+		return nil, err
+	}
 
 	root := &URLNode{URL: startURL}
 
+	c.mu.Lock()
 	c.Root = root
+	c.mu.Unlock()
+
+	c.Graph = graph.New(c.key(parsedURL))
+
+	c.frontier = newFrontier()
+
+	// Keep the frontier open while it is being seeded, even if the start URL itself is skipped:
+	c.frontier.hold()
+
+	parent := ctx
+
+	// The duration budget is a deadline of its own, which aborts the pages being fetched when it expires:
+	if c.config.MaxDuration > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeoutCause(ctx, c.config.MaxDuration, fmt.Errorf("%w: %s", ErrBudgetExceeded, BudgetMaxDuration))
+
+		defer cancel()
+	}
+
+	// Cancelling the context closes the frontier, so idle workers exit and no new pages are scheduled:
+	stop := context.AfterFunc(ctx, func() {
+		if parent.Err() == nil && errors.Is(context.Cause(ctx), ErrBudgetExceeded) {
+			c.mu.Lock()
+			c.stopLocked(BudgetMaxDuration)
+			c.mu.Unlock()
+		}
+
+		c.frontier.close()
+	})
+
+	defer stop()
+
+	for i := 0; i < c.config.Concurrency; i++ {
+		c.wg.Add(1)
+		go c.worker(ctx, maxDepth)
+	}
+
+	skipped := c.skipReason(ctx, parsedURL)
+
+	c.mu.Lock()
+	root.Skipped = skipped
+	c.mu.Unlock()
+
+	if skipped == "" {
+		c.frontier.push(task{url: startURL, node: root, depth: 0})
+	}
+
+	if c.config.Sitemaps {
+		c.seedFromSitemaps(ctx, root, parsedURL)
+	}
+
+	c.frontier.done()
 
-	c.wg.Add(1)
-	go c.crawlRecursive(startURL, c.Root, 0, maxDepth)
 	c.wg.Wait()
 
 	c.done <- true
 
-	return root, nil
+	if err := parent.Err(); err != nil {
+		return root, err
+	}
+
+	return root, c.budgetError()
 }
 
 /*****************************************************************************************************************/
 
-func (c *Crawler) crawlRecursive(currentURL string, node *URLNode, depth int, maxDepth int) {
+// worker pulls tasks from the frontier until it is closed, i.e., the crawl is complete or cancelled.
+func (c *Crawler) worker(ctx context.Context, maxDepth int) {
 	defer c.wg.Done()
 
-	if depth > maxDepth || c.hasVisited(currentURL) {
+	for {
+		t, ok := c.frontier.pop()
+
+		if !ok {
+			return
+		}
+
+		c.crawlPage(ctx, t, maxDepth)
+
+		c.frontier.done()
+	}
+}
+
+/*****************************************************************************************************************/
+
+// crawlPage fetches a single page, attaches its in-scope links to the node and pushes them onto the frontier.
+func (c *Crawler) crawlPage(ctx context.Context, t task, maxDepth int) {
+	// Stop scheduling any further work once the context has been cancelled:
+	if ctx.Err() != nil {
+		return
+	}
+
+	current, err := url.Parse(t.url)
+
+	if err != nil {
+		return
+	}
+
+	// Pages are deduplicated by their canonical URL, so different spellings are only fetched once:
+	key := c.key(current)
+
+	if t.depth > maxDepth || !c.markAsVisited(key) {
 		return
 	}
 
-	c.markAsVisited(currentURL)
+	if budget := c.reservePage(current.Hostname()); budget != "" {
+		c.mu.Lock()
+		t.node.Skipped = budget
+		c.mu.Unlock()
+
+		return
+	}
+
+	p, attempts, err := c.fetch(ctx, t.url)
+
+	c.record(t.node, p, attempts, err)
+
+	if p != nil {
+		c.spendBytes(p.bytesRead)
+	}
 
-	links, err := c.fetchAndParse(currentURL)
 	if err != nil {
 		return
 	}
 
-	for _, link := range links {
-		parsedLink, err := url.Parse(link)
+	// A page reached through redirects is known by its final URL too, which is not fetched again, and whose links
+	// are not followed again when another URL already led to it:
+	if final, err := url.Parse(p.finalURL); err == nil && len(p.redirects) > 0 {
+		if finalKey := c.key(final); finalKey != key && !c.markAsVisited(finalKey) {
+			return
+		}
+	}
+
+	for _, link := range p.links {
+		parsedLink, err := url.Parse(link.URL)
 
-		if err != nil || parsedLink.Host != c.baseDomain {
+		if err != nil || !c.inScope(parsedLink) {
 			continue
 		}
 
-		childNode := &URLNode{URL: link}
+		// The graph keeps every link, including those to pages which have already been visited:
+		c.Graph.AddEdge(graph.Edge{
+			From:       key,
+			To:         c.key(parsedLink),
+			Href:       link.Href,
+			AnchorText: link.Text,
+			Title:      link.Title,
+			Rel:        link.Rel,
+			Hreflang:   link.Hreflang,
+			Region:     link.Region,
+			Element:    link.Element,
+			Attrs:      link.Attrs,
+		})
+
+		skipped := c.noFollowReason(p, link)
+
+		if skipped == "" {
+			skipped = c.skipReason(ctx, parsedLink)
+		}
+
+		childNode := &URLNode{URL: link.URL, Skipped: skipped, Depth: t.depth + 1}
 
 		c.mu.Lock()
-		node.Links = append(node.Links, childNode)
+		t.node.Links = append(t.node.Links, childNode)
 		c.mu.Unlock()
 
-		c.stream <- childNode // send childNode to the channel
+		// Send childNode to the channel, unless the consumer has gone away and the context is done:
+		select {
+		case c.stream <- childNode:
+		case <-ctx.Done():
+			return
+		}
 
-		c.wg.Add(1)
+		if childNode.Skipped == "" && t.depth < maxDepth {
+			// A link found once a budget has stopped the crawl is skipped like those which were already queued:
+			if !c.frontier.push(task{url: link.URL, node: childNode, depth: t.depth + 1}) {
+				c.mu.Lock()
+				childNode.Skipped = c.stopReason
+				c.mu.Unlock()
+			}
+		}
+	}
 
-		go func(link string) { // Ensure to pass link as an argument to avoid closure pitfalls
-			c.crawlRecursive(link, childNode, depth+1, maxDepth)
-		}(link)
+	if c.config.CheckResources {
+		c.checkResources(ctx, t.node)
 	}
 }
 
 /*****************************************************************************************************************/
 
-// isVisited checks if a URL has already been visited.
-func (c *Crawler) hasVisited(url string) bool {
+// markAsVisited marks a URL as visited, reporting false if it had already been visited. Checking and marking
+// happen atomically, so concurrent workers never fetch the same page twice.
+func (c *Crawler) markAsVisited(url string) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.visited[url]
+
+	if c.visited[url] {
+		return false
+	}
+
+	c.visited[url] = true
+
+	return true
 }
 
 /*****************************************************************************************************************/
 
-// markAsVisited marks a URL as visited.
-func (c *Crawler) markAsVisited(url string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.visited[url] = true
+// inScope checks the canonical form of a link against the scope rules, so that case, default ports and
+// tracking parameters do not matter.
+func (c *Crawler) inScope(link *url.URL) bool {
+	return c.scope.Contains(c.canonical(link))
+}
+
+/*****************************************************************************************************************/
+
+// canonical normalises a URL with the configured canonicalisation options.
+func (c *Crawler) canonical(link *url.URL) *url.URL {
+	return validate.CanonicalizeURL(link, *c.config.Canonical)
+}
+
+/*****************************************************************************************************************/
+
+// key returns the canonical URL identifying a page, used for deduplication and in the link graph.
+func (c *Crawler) key(link *url.URL) string {
+	return c.canonical(link).String()
+}
+
+/*****************************************************************************************************************/
+
+// skipReason reports why an in-scope link should not be crawled, or an empty string if it should be.
+func (c *Crawler) skipReason(ctx context.Context, link *url.URL) string {
+	if !c.robotsAllowed(ctx, link) {
+		return SkippedByRobots
+	}
+
+	return ""
+}
+
+/*****************************************************************************************************************/
+
+// noFollowReason reports why a link should not be followed because of a nofollow directive of the page or of
+// the link itself, or an empty string if it should be followed.
+func (c *Crawler) noFollowReason(p *page, link parse.Link) string {
+	if c.config.IgnoreNoFollow {
+		return ""
+	}
+
+	if p.directives.NoFollow {
+		return SkippedByNoFollowPage
+	}
+
+	if slices.Contains(link.Rel, "nofollow") {
+		return SkippedByNoFollowLink
+	}
+
+	return ""
+}
+
+/*****************************************************************************************************************/
+
+// robotsAllowed checks the robots.txt rules of the link's host, applying any Crawl-delay to the host limiter.
+func (c *Crawler) robotsAllowed(ctx context.Context, link *url.URL) bool {
+	if c.config.IgnoreRobots {
+		return true
+	}
+
+	for _, pattern := range c.config.IgnoreRobotsHosts {
+		if limit.MatchHost(pattern, link.Host) {
+			return true
+		}
+	}
+
+	rules := c.robots.Get(ctx, link)
+
+	c.limiter.SetMinInterval(link.Host, rules.CrawlDelay(c.config.UserAgent))
+
+	return rules.Allowed(c.config.UserAgent, link.RequestURI())
 }
 
 /*****************************************************************************************************************/
 
-// fetchAndParse retrieves the HTML content from the specified URL and extracts links.
-func (c *Crawler) fetchAndParse(urlStr string) ([]string, error) {
-	resp, err := c.client.Get(urlStr)
+// get performs a GET request, see request.
+func (c *Crawler) get(ctx context.Context, urlStr string) (*http.Response, time.Time, error) {
+	return c.request(ctx, http.MethodGet, urlStr)
+}
+
+/*****************************************************************************************************************/
+
+// request performs a request with the crawler's User-Agent, once the host's rate and concurrency limits allow
+// it, returning the response and the time the request was sent. The host's concurrency slot is held until the
+// response body is closed.
+func (c *Crawler) request(ctx context.Context, method, urlStr string) (*http.Response, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
 
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
+	}
+
+	req.Header.Set("User-Agent", c.config.UserAgent)
+
+	// Wait until the host's rate and concurrency limits allow another request:
+	release, err := c.limiter.Acquire(ctx, req.URL.Host)
+
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	sentAt := time.Now()
+
+	resp, err := c.client.Do(req)
+
+	// The host's health drives its adaptive concurrency limit, if any, but a cancelled request says nothing about it:
+	if ctx.Err() == nil {
+		c.limiter.Observe(req.URL.Host, outcome(resp, err, time.Since(sentAt)))
+	}
+
+	if err != nil {
+		release()
+		return nil, sentAt, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+
+	return resp, sentAt, nil
+}
+
+/*****************************************************************************************************************/
+
+// outcome describes a completed request for the host limiter: 429 and 503 responses ask us to slow down, while
+// transport errors and other 5xx responses are failures.
+func outcome(resp *http.Response, err error, latency time.Duration) limit.Outcome {
+	if err != nil {
+		return limit.Outcome{Latency: latency, Failed: true}
+	}
+
+	return limit.Outcome{
+		Latency:   latency,
+		Throttled: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable,
+		Failed:    resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusServiceUnavailable,
+	}
+}
+
+/*****************************************************************************************************************/
+
+// fetchAndParse retrieves the content from the specified URL and extracts links and metadata with the parser
+// registered for its media type. Errors wrap one of ErrTransport, ErrRedirect, ErrBadStatus, ErrBodyTooLarge,
+// ErrUnsupportedContentType or ErrParse; the page is still returned alongside all but ErrTransport, describing the
+// response that was received.
+func (c *Crawler) fetchAndParse(ctx context.Context, urlStr string) (*page, error) {
+	ctx, trace := withRedirectTrace(ctx)
+
+	resp, sentAt, err := c.get(ctx, urlStr)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTransport, err)
 	}
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/html" {
-		return nil, fmt.Errorf("non-200 status code received: %d", resp.StatusCode)
+	p := &page{
+		statusCode:    resp.StatusCode,
+		finalURL:      urlStr,
+		contentType:   resp.Header.Get("Content-Type"),
+		contentLength: max(resp.ContentLength, 0),
+		fetchedAt:     sentAt,
+	}
+
+	// Only the bytes actually read count against the byte budget, whatever length the response declares:
+	counter := &countingReader{Reader: resp.Body}
+
+	defer func() { p.bytesRead = counter.n }()
+
+	// Headers may carry indexing directives for any kind of response, e.g., PDFs:
+	p.directives = robots.ParseHeader(resp.Header.Values("X-Robots-Tag"), c.config.UserAgent)
+
+	p.redirects = trace.hops
+
+	// The request of the response is the last one made, i.e., after following any redirects:
+	if resp.Request != nil {
+		p.finalURL = resp.Request.URL.String()
+	} else if trace.final != nil {
+		p.finalURL = trace.final.String()
+	}
+
+	// The redirect response which ended the chain is not worth parsing:
+	if trace.err != nil {
+		p.responseTime = time.Since(sentAt)
+		return p, fmt.Errorf("%w: %w", ErrRedirect, trace.err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			p.retryAfter = &retryAfter
+		}
+
+		p.responseTime = time.Since(sentAt)
+		return p, fmt.Errorf("%w: %d", ErrBadStatus, resp.StatusCode)
+	}
+
+	// A declared length over the limit is not worth downloading at all:
+	if resp.ContentLength > c.config.MaxBodySize {
+		p.responseTime = time.Since(sentAt)
+		return p, fmt.Errorf("%w: %d bytes declared, limit %d", ErrBodyTooLarge, resp.ContentLength, c.config.MaxBodySize)
+	}
+
+	// Bodies without a declared length, or lying about it, are cut off at the limit and parsed as far as they go:
+	limited := &limitedReader{Reader: counter, n: c.config.MaxBodySize}
+
+	body := bufio.NewReader(limited)
+
+	header := p.contentType
+
+	contentType, mediaType := detectContentType(header, body)
+
+	p.contentType = contentType
+
+	parser, ok := c.config.Parsers.Lookup(mediaType)
+
+	if !ok {
+		p.responseTime = time.Since(sentAt)
+		return p, fmt.Errorf("%w: %q", ErrUnsupportedContentType, mediaType)
+	}
+
+	var document io.Reader = body
+
+	// Text documents are transcoded here, while XML declares its own encoding, which its decoders transcode, and binary
+	// bodies are left alone. The sniffed Content-Type always claims UTF-8, so only the charset of the header counts:
+	if isText(mediaType) {
+		document, p.charset = parse.NewUTF8Reader(body, header)
+	}
+
+	// Relative links resolve against the URL the page was actually served from, after any redirects:
+	doc, err := parser.Parse(document, p.finalURL)
+
+	if err != nil {
+		p.contentLength = min(counter.n, c.config.MaxBodySize)
+		p.truncated = limited.truncated
+		p.responseTime = time.Since(sentAt)
+		return p, fmt.Errorf("%w: %w", ErrParse, err)
+	}
+
+	// Pages take directives from <meta name="robots"> and from meta tags naming our product token:
+	for _, name := range []string{"robots", robots.Agent(c.config.UserAgent)} {
+		for _, content := range doc.Meta[name] {
+			p.directives = p.directives.Merge(robots.ParseDirectives(content))
+		}
+	}
+
+	p.links = doc.Links
+	p.resources = doc.Resources
+	p.entries = doc.Entries
+	p.meta = doc.Page
+	p.structured = doc.Structured
+
+	for _, feed := range doc.Feeds {
+		p.feeds = append(p.feeds, feed.URL)
 	}
+	p.title = doc.Title
+	p.contentLength = min(counter.n, c.config.MaxBodySize)
+	p.truncated = limited.truncated
+	p.responseTime = time.Since(sentAt)
+
+	return p, nil
+}
+
+/*****************************************************************************************************************/
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+/*****************************************************************************************************************/
+
+// Read reads from the underlying reader, adding to the count.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+/*****************************************************************************************************************/
+
+// limitedReader reads at most n bytes from the underlying reader, like io.LimitedReader, noting whether it was
+// cut off before the end.
+type limitedReader struct {
+	io.Reader
+	n         int64
+	truncated bool
+}
+
+/*****************************************************************************************************************/
+
+// Read reads from the underlying reader until the limit is reached, after which it reports io.EOF.
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		// One more byte tells a body of exactly the limit apart from a longer one:
+		var b [1]byte
+
+		if n, _ := r.Reader.Read(b[:]); n > 0 {
+			r.truncated = true
+		}
+
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+
+	n, err := r.Reader.Read(p)
+	r.n -= int64(n)
+	return n, err
+}
+
+/*****************************************************************************************************************/
+
+// limitedClient sends requests with the crawler's client once the host limiter allows them, for packages which make
+// requests of their own, e.g., the robots.txt cache.
+type limitedClient struct {
+	crawler *Crawler
+}
+
+/*****************************************************************************************************************/
+
+// Do sends a request, see request.
+func (l limitedClient) Do(req *http.Request) (*http.Response, error) {
+	resp, _, err := l.crawler.request(req.Context(), req.Method, req.URL.String())
+	return resp, err
+}
+
+/*****************************************************************************************************************/
+
+// releaseOnClose is a response body which releases the host limiter slot of its request once closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+/*****************************************************************************************************************/
 
-	return parse.AhrefsFromHTML(resp.Body, urlStr), nil
+// Close closes the underlying body and releases the limiter slot.
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
 }
 
 /*****************************************************************************************************************/
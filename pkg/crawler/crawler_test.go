@@ -9,17 +9,53 @@ package crawler
 /*****************************************************************************************************************/
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	parse "github.com/michealroberts/koroutine-web-crawler/pkg/parsers"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/scope"
 	"github.com/stretchr/testify/assert"
 )
 
 /*****************************************************************************************************************/
 
+// activateMock activates the http mock for the default HTTP client, see mockMissingRobots.
+func activateMock() {
+	httpmock.Activate()
+
+	mockMissingRobots()
+}
+
+/*****************************************************************************************************************/
+
+// mockMissingRobots responds to the robots.txt of every host as missing, which allows everything, as an unreachable
+// one would disallow everything. Tests may still register a robots.txt of their own.
+func mockMissingRobots() {
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`/robots\.txt$`), httpmock.NewStringResponder(404, ""))
+}
+
+/*****************************************************************************************************************/
+
+// pageCallCount returns the number of requests made to the mock, other than for the robots.txt files it mocks.
+func pageCallCount() int {
+	return httpmock.GetTotalCallCount() - httpmock.GetCallCountInfo()[`GET =~/robots\.txt$`]
+}
+
+/*****************************************************************************************************************/
+
 func TestCrawlerInitialization(t *testing.T) {
-	c := New()
+	c, err := New()
+	assert.NoError(t, err)
 	assert.NotNil(t, c)
 	assert.IsType(t, &http.Client{}, c.client)
 	assert.NotNil(t, c.visited)
@@ -29,7 +65,7 @@ func TestCrawlerInitialization(t *testing.T) {
 
 func TestCrawlValidStartURL(t *testing.T) {
 	// Activate the http mock for the default HTTP client used globally
-	httpmock.Activate()
+	activateMock()
 	defer httpmock.DeactivateAndReset()
 
 	// Setup the base URL and the HTML content it should return
@@ -42,8 +78,9 @@ func TestCrawlValidStartURL(t *testing.T) {
 			return resp, nil
 		})
 
-	// Create a new instance of the crawler
-	c := New()
+	// Create a new instance of the crawler, without retrying the unmocked link
+	c, err := New(WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	assert.NoError(t, err)
 	// Perform the crawl operation starting from the base URL
 	rootNode, err := c.Crawl(baseURL, 1)
 
@@ -62,7 +99,7 @@ func TestCrawlValidStartURL(t *testing.T) {
 /*****************************************************************************************************************/
 
 func TestCrawlIgnoreExternalLinks(t *testing.T) {
-	httpmock.Activate()
+	activateMock()
 	defer httpmock.DeactivateAndReset()
 
 	baseURL := "https://koroutine.tech"
@@ -74,7 +111,8 @@ func TestCrawlIgnoreExternalLinks(t *testing.T) {
 			return resp, nil
 		})
 
-	c := New()
+	c, err := New()
+	assert.NoError(t, err)
 	root, err := c.Crawl(baseURL, 1)
 
 	assert.NoError(t, err)
@@ -84,15 +122,59 @@ func TestCrawlIgnoreExternalLinks(t *testing.T) {
 
 /*****************************************************************************************************************/
 
+func TestCrawlScopeRules(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL+"/docs/",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `
+				<a href="https://koroutine.tech/docs/intro">Intro</a>
+				<a href="https://koroutine.tech/docs/manual.pdf">Manual</a>
+				<a href="https://koroutine.tech/about">About</a>
+				<a href="https://blog.koroutine.tech/docs/">Blog</a>
+				<a href="https://external.com/docs/">External</a>
+			`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New(WithScope(scope.Rules{
+		IncludeSubdomains: true,
+		PathPrefixes:      []string{"/docs/"},
+		Exclude:           []string{"*.pdf"},
+	}))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL+"/docs/", 0)
+
+	assert.NoError(t, err)
+
+	var urls []string
+
+	for _, link := range root.Links {
+		urls = append(urls, link.URL)
+	}
+
+	assert.Equal(t, []string{
+		"https://koroutine.tech/docs/intro",
+		"https://blog.koroutine.tech/docs/",
+	}, urls)
+}
+
+/*****************************************************************************************************************/
+
 func TestCrawlNon200StatusCode(t *testing.T) {
-	httpmock.Activate()
+	activateMock()
 	defer httpmock.DeactivateAndReset()
 
 	testURL := "https://koroutine.tech"
 	httpmock.RegisterResponder("GET", testURL,
 		httpmock.NewStringResponder(404, ""))
 
-	c := New()
+	c, err := New()
+	assert.NoError(t, err)
 	root, err := c.Crawl(testURL, 1)
 
 	assert.NoError(t, err)
@@ -102,50 +184,1097 @@ func TestCrawlNon200StatusCode(t *testing.T) {
 
 /*****************************************************************************************************************/
 
-func TestCrawlerConcurrency(t *testing.T) {
-	httpmock.Activate()
+func TestCrawlHTMLContentTypes(t *testing.T) {
+	activateMock()
 	defer httpmock.DeactivateAndReset()
 
 	baseURL := "https://koroutine.tech"
-	link1 := "https://koroutine.tech/page1"
-	link2 := "https://koroutine.tech/page2"
 
 	httpmock.RegisterResponder("GET", baseURL,
 		func(req *http.Request) (*http.Response, error) {
-			resp := httpmock.NewStringResponse(200, `<a href="/page1">Page 1</a><a href="/page2">Page 2</a>`)
+			resp := httpmock.NewStringResponse(200, `<a href="/xhtml">XHTML</a><a href="/sniffed">Sniffed</a>`)
+			resp.Header.Add("Content-Type", "text/html; charset=utf-8")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/xhtml",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<html xmlns="http://www.w3.org/1999/xhtml"><a href="/from-xhtml">Link</a></html>`)
+			resp.Header.Add("Content-Type", "application/xhtml+xml")
+			return resp, nil
+		})
+
+	// No Content-Type header at all, so the type has to be sniffed from the body:
+	httpmock.RegisterResponder("GET", baseURL+"/sniffed",
+		httpmock.NewStringResponder(200, `<!DOCTYPE html><html><body><a href="/from-sniffed">Link</a></body></html>`))
+
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 2)
+	assert.Len(t, root.Links[0].Links, 1)
+	assert.Equal(t, baseURL+"/from-xhtml", root.Links[0].Links[0].URL)
+	assert.Len(t, root.Links[1].Links, 1)
+	assert.Equal(t, baseURL+"/from-sniffed", root.Links[1].Links[0].URL)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlRecordsPageMetadata(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	body := `<html lang="en"><head><title>Koroutine</title><meta name="description" content="Crawling"></head><body><h1>Home</h1><a href="/missing">Missing</a><a href="/report.pdf">PDF</a></body></html>`
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, body)
+			resp.Header.Add("Content-Type", "text/html; charset=utf-8")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/missing",
+		httpmock.NewStringResponder(404, "Not Found"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/report.pdf",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "%PDF-1.7")
+			resp.Header.Add("Content-Type", "application/pdf")
+			resp.ContentLength = 8
+			return resp, nil
+		})
+
+	c, err := New()
+	assert.NoError(t, err)
+	before := time.Now()
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, root.StatusCode)
+	assert.Equal(t, baseURL, root.FinalURL)
+	assert.Equal(t, "text/html; charset=utf-8", root.ContentType)
+	assert.Equal(t, int64(len(body)), root.ContentLength)
+	assert.Equal(t, "Koroutine", root.Title)
+	assert.Equal(t, "Koroutine", root.Meta.Title)
+	assert.Equal(t, "Crawling", root.Meta.Description)
+	assert.Equal(t, "en", root.Meta.Lang)
+	assert.Equal(t, []parse.Heading{{Level: 1, Text: "Home"}}, root.Meta.Headings)
+	assert.Equal(t, 3, root.Meta.WordCount)
+	assert.Equal(t, 0, root.Depth)
+	assert.Empty(t, root.Error)
+	assert.NotNil(t, root.FetchedAt)
+	assert.False(t, root.FetchedAt.Before(before))
+
+	assert.Len(t, root.Links, 2)
+
+	missing := root.Links[0]
+	assert.Equal(t, 1, missing.Depth)
+	assert.Equal(t, 404, missing.StatusCode)
+	assert.Contains(t, missing.Error, ErrBadStatus.Error())
+
+	pdf := root.Links[1]
+	assert.Equal(t, 200, pdf.StatusCode)
+	assert.Equal(t, "application/pdf", pdf.ContentType)
+	assert.Equal(t, int64(8), pdf.ContentLength)
+	assert.Contains(t, pdf.Error, ErrUnsupportedContentType.Error())
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlResolvesLinksAgainstFinalURL(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL+"/old",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusMovedPermanently, "")
+			resp.Header.Add("Location", "/new/")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/new/",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="page">Page</a>`)
 			resp.Header.Add("Content-Type", "text/html")
+			resp.Request = req // as set by a real transport
 			return resp, nil
 		})
 
-	httpmock.RegisterResponder("GET", link1,
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL+"/old", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, baseURL+"/new/", root.FinalURL)
+	assert.Len(t, root.Links, 1)
+	assert.Equal(t, baseURL+"/new/page", root.Links[0].URL)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlBuildsLinkGraph(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	pages := map[string]string{
+		baseURL:            `<nav><a href="/a" rel="author" title="First page">Page <em>A</em></a></nav>`,
+		baseURL + "/a":     `<a href="/b">B</a><a href="https://koroutine.tech#top">Home</a>`,
+		baseURL + "/b":     `<a href="/a">Back to A</a>`,
+		baseURL + "/other": ``,
+	}
+
+	for pageURL, body := range pages {
+		body := body
+
+		httpmock.RegisterResponder("GET", pageURL,
+			func(req *http.Request) (*http.Response, error) {
+				resp := httpmock.NewStringResponse(200, body)
+				resp.Header.Add("Content-Type", "text/html")
+				return resp, nil
+			})
+	}
+
+	c, err := New()
+	assert.NoError(t, err)
+	_, err = c.Crawl(baseURL, 3)
+	assert.NoError(t, err)
+
+	g := c.Graph
+
+	// Pages are keyed by canonical URL, so the root gains its trailing slash:
+	rootKey := baseURL + "/"
+
+	assert.Equal(t, []string{rootKey, baseURL + "/a", baseURL + "/b"}, g.Nodes())
+
+	// The link back to the already visited root is kept, with the fragment ignored:
+	inlinks := g.Inlinks(rootKey)
+	assert.Len(t, inlinks, 1)
+	assert.Equal(t, baseURL+"/a", inlinks[0].From)
+	assert.Equal(t, "Home", inlinks[0].AnchorText)
+
+	outlinks := g.Outlinks(rootKey)
+	assert.Len(t, outlinks, 1)
+	assert.Equal(t, "Page A", outlinks[0].AnchorText)
+	assert.Equal(t, "/a", outlinks[0].Href)
+	assert.Equal(t, "First page", outlinks[0].Title)
+	assert.Equal(t, []string{"author"}, outlinks[0].Rel)
+	assert.Equal(t, "nav", outlinks[0].Region)
+	assert.Equal(t, "a", outlinks[0].Element)
+	assert.Equal(t, "author", outlinks[0].Attrs["rel"])
+
+	assert.Len(t, g.Inlinks(baseURL+"/a"), 2)
+	assert.Equal(t, []string{rootKey, baseURL + "/a", baseURL + "/b"}, g.ShortestPath(baseURL+"/b"))
+
+	// Every page can reach every other page, so they form a single component:
+	components := g.StronglyConnectedComponents()
+	assert.Len(t, components, 1)
+	assert.Len(t, components[0], 3)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlDeduplicatesCanonicalURLs(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `
+				<a href="/a">A</a>
+				<a href="/a/">A with slash</a>
+				<a href="/a#top">A with fragment</a>
+				<a href="HTTPS://Koroutine.TECH:443/a">A shouting</a>
+				<a href="/a?utm_source=newsletter">A tracked</a>
+				<a href="/q?b=1&a=2">Q</a>
+				<a href="/q?a=2&b=1">Q sorted</a>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`^(?i)https://koroutine\.tech(:443)?/[aq]`),
 		func(req *http.Request) (*http.Response, error) {
 			resp := httpmock.NewStringResponse(200, "")
 			resp.Header.Add("Content-Type", "text/html")
 			return resp, nil
 		})
 
-	httpmock.RegisterResponder("GET", link2,
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	// Every spelling is kept in the tree and considered in scope, but only fetched once:
+	assert.Len(t, root.Links, 7)
+	assert.Equal(t, 3, pageCallCount())
+	assert.Len(t, c.Graph.Inlinks(baseURL+"/a"), 5)
+	assert.Len(t, c.Graph.Inlinks(baseURL+"/q?a=2&b=1"), 2)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlChecksResources(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `
+				<link rel="stylesheet" href="/missing.css">
+				<img src="/logo.png" srcset="/logo.png 1x">
+				<script src="https://cdn.example.com/app.js"></script>
+				<video poster="/poster.jpg"></video>
+				<a href="/about">About</a>
+			`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/about",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<img src="/logo.png">`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("HEAD", baseURL+"/missing.css",
+		httpmock.NewStringResponder(404, ""))
+
+	httpmock.RegisterResponder("HEAD", baseURL+"/logo.png",
 		func(req *http.Request) (*http.Response, error) {
 			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "image/png")
+			return resp, nil
+		})
+
+	// Servers which do not support HEAD are checked with a GET instead:
+	httpmock.RegisterResponder("HEAD", baseURL+"/poster.jpg",
+		httpmock.NewStringResponder(405, ""))
+
+	httpmock.RegisterResponder("GET", baseURL+"/poster.jpg",
+		httpmock.NewStringResponder(200, "JPEG"))
+
+	c, err := New(WithResourceChecks(), WithConcurrency(1))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Resources, 5)
+
+	css := root.Resources[0]
+	assert.Equal(t, "link", css.Element)
+	assert.Equal(t, 404, css.StatusCode)
+	assert.Contains(t, css.Error, ErrBadStatus.Error())
+
+	logo := root.Resources[1]
+	assert.Equal(t, "img", logo.Element)
+	assert.Equal(t, "src", logo.Attribute)
+	assert.Equal(t, 200, logo.StatusCode)
+	assert.Equal(t, "image/png", logo.ContentType)
+	assert.Equal(t, "srcset", root.Resources[2].Attribute)
+	assert.Equal(t, 200, root.Resources[2].StatusCode)
+
+	cdn := root.Resources[3]
+	assert.Equal(t, SkippedOutOfScope, cdn.Skipped)
+	assert.Zero(t, cdn.StatusCode)
+
+	assert.Equal(t, 200, root.Resources[4].StatusCode)
+
+	// The logo is linked three times from two pages, but only checked once:
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["HEAD "+baseURL+"/logo.png"])
+	assert.Equal(t, 200, root.Links[0].Resources[0].StatusCode)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlSkipsResourceChecksByDefault(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<img src="/logo.png">`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*Resource{{URL: baseURL + "/logo.png", Element: "img", Attribute: "src"}}, root.Resources)
+	assert.Equal(t, 1, pageCallCount())
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlCustomParser(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="/api/pages">API</a><a href="/urls.txt">URLs</a>`)
 			resp.Header.Add("Content-Type", "text/html")
 			return resp, nil
 		})
 
-	c := New()
+	httpmock.RegisterResponder("GET", baseURL+"/api/pages",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `{"links": ["/api/pages/1"]}`)
+			resp.Header.Add("Content-Type", "application/json")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/urls.txt",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "https://koroutine.tech/from-text\n")
+			resp.Header.Add("Content-Type", "text/plain")
+			return resp, nil
+		})
+
+	jsonParser := parse.ParserFunc(func(body io.Reader, base string) (*parse.Document, error) {
+		var data struct {
+			Links []string `json:"links"`
+		}
+
+		if err := json.NewDecoder(body).Decode(&data); err != nil {
+			return nil, err
+		}
+
+		doc := &parse.Document{Base: base}
+
+		for _, link := range data.Links {
+			doc.Links = append(doc.Links, parse.Link{Href: link, URL: "https://koroutine.tech" + link})
+		}
+
+		return doc, nil
+	})
+
+	c, err := New(WithParser("application/json", jsonParser), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	assert.NoError(t, err)
 	root, err := c.Crawl(baseURL, 2)
 
 	assert.NoError(t, err)
-	assert.NotNil(t, root)
 	assert.Len(t, root.Links, 2)
-	assert.Equal(t, link1, root.Links[0].URL)
-	assert.Equal(t, link2, root.Links[1].URL)
+
+	api := root.Links[0]
+	assert.Empty(t, api.Error)
+	assert.Len(t, api.Links, 1)
+	assert.Equal(t, baseURL+"/api/pages/1", api.Links[0].URL)
+
+	text := root.Links[1]
+	assert.Empty(t, text.Error)
+	assert.Len(t, text.Links, 1)
+	assert.Equal(t, baseURL+"/from-text", text.Links[0].URL)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlFollowsFeeds(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<link rel="alternate" type="application/rss+xml" href="/feed.xml">`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/feed.xml",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<rss version="2.0"><channel><title>Blog</title>
+				<item><title>Post</title><link>/blog/post</link><pubDate>Wed, 01 May 2024 12:00:00 GMT</pubDate></item>
+				<item><title>Undated</title><link>/blog/undated</link></item>
+			</channel></rss>`)
+			resp.Header.Add("Content-Type", "application/rss+xml")
+			return resp, nil
+		})
+
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`/blog/`),
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<title>Post</title>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New(WithHostPolicy(limit.Policy{}))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{baseURL + "/feed.xml"}, root.Feeds)
+
+	feed := root.Links[0]
+	assert.Equal(t, "Blog", feed.Title)
+	assert.Len(t, feed.Entries, 2)
+	assert.Equal(t, baseURL+"/blog/post", feed.Entries[0].URL)
+	assert.Equal(t, "Post", feed.Entries[0].Title)
+	assert.True(t, feed.Entries[0].Published.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Nil(t, feed.Entries[1].Published)
+
+	// The items of the feed are crawled like any other link:
+	assert.Len(t, feed.Links, 2)
+	assert.Equal(t, 200, feed.Links[0].StatusCode)
+	assert.Equal(t, "Post", feed.Links[0].Title)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlReportsStructuredDataTypes(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	pages := map[string]string{
+		baseURL: `<script type="application/ld+json">{"@type": "WebSite"}</script>
+			<script type="application/ld+json">{"@type": "Organization"}</script>
+			<a href="/product">Product</a><a href="/plain">Plain</a>`,
+		baseURL + "/product": `<script type="application/ld+json">{"@type": ["Product", "Organization"]}</script>
+			<div itemscope itemtype="https://schema.org/Product"><span itemprop="name">Crawler</span></div>`,
+		baseURL + "/plain": `<p>No structured data</p>`,
+	}
+
+	for pageURL, body := range pages {
+		body := body
+
+		httpmock.RegisterResponder("GET", pageURL,
+			func(req *http.Request) (*http.Response, error) {
+				resp := httpmock.NewStringResponse(200, body)
+				resp.Header.Add("Content-Type", "text/html")
+				return resp, nil
+			})
+	}
+
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+	assert.NoError(t, err)
+
+	assert.Len(t, root.StructuredData.JSONLD, 2)
+
+	product := root.Links[0]
+	assert.Len(t, product.StructuredData.Microdata, 1)
+	assert.Equal(t, []any{"Crawler"}, product.StructuredData.Microdata[0].Properties["name"])
+
+	assert.Nil(t, root.Links[1].StructuredData)
+
+	// Types are counted once per page, however often a page uses them:
+	assert.Equal(t, []TypeCount{
+		{Type: "Organization", Pages: 2},
+		{Type: "Product", Pages: 1},
+		{Type: "WebSite", Pages: 1},
+	}, c.StructuredDataTypes())
+}
+
+/*****************************************************************************************************************/
+
+func TestFetchAndParseErrorKinds(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL+"/missing",
+		httpmock.NewStringResponder(404, ""))
+
+	httpmock.RegisterResponder("GET", baseURL+"/document.pdf",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "%PDF-1.7")
+			resp.Header.Add("Content-Type", "application/pdf")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/broken",
+		httpmock.NewErrorResponder(fmt.Errorf("connection reset")))
+
+	httpmock.RegisterResponder("GET", baseURL+"/feed.xml",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "<rss><channel>")
+			resp.Header.Add("Content-Type", "application/rss+xml")
+			return resp, nil
+		})
+
+	c, err := New()
+	assert.NoError(t, err)
+
+	_, err = c.fetchAndParse(context.Background(), baseURL+"/missing")
+	assert.ErrorIs(t, err, ErrBadStatus)
+	assert.Contains(t, err.Error(), "404")
+
+	_, err = c.fetchAndParse(context.Background(), baseURL+"/document.pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+	assert.Contains(t, err.Error(), "application/pdf")
+
+	p, err := c.fetchAndParse(context.Background(), baseURL+"/feed.xml")
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Equal(t, 200, p.statusCode)
+
+	_, err = c.fetchAndParse(context.Background(), baseURL+"/broken")
+	assert.ErrorIs(t, err, ErrTransport)
+	assert.NotErrorIs(t, err, ErrBadStatus)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlerConcurrency(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+	link1 := "https://koroutine.tech/page1"
+	link2 := "https://koroutine.tech/page2"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="/page1">Page 1</a><a href="/page2">Page 2</a>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", link1,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", link2,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 2)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, root)
+	assert.Len(t, root.Links, 2)
+	assert.Equal(t, link1, root.Links[0].URL)
+	assert.Equal(t, link2, root.Links[1].URL)
+}
+
+/*****************************************************************************************************************/
+
+// registerLinkFarm registers a root page linking to n empty pages, returning the peak number of concurrent
+// requests made to those pages.
+func registerLinkFarm(baseURL string, n int) *int32 {
+	var links strings.Builder
+
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&links, `<a href="/page%d">Page %d</a>`, i, i)
+	}
+
+	var inFlight, maxInFlight int32
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, links.String())
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`^`+regexp.QuoteMeta(baseURL)+`/page\d+$`),
+		func(req *http.Request) (*http.Response, error) {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	return &maxInFlight
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlerBoundedConcurrency(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	maxInFlight := registerLinkFarm(baseURL, 20)
+
+	// Lift the per-host limits, so only the global worker limit applies:
+	c, err := New(WithConcurrency(3), WithHostPolicy(limit.Policy{}))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 20)
+	assert.Equal(t, 21, pageCallCount())
+	assert.LessOrEqual(t, atomic.LoadInt32(maxInFlight), int32(3))
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlerHostPolicy(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	maxInFlight := registerLinkFarm(baseURL, 10)
+
+	c, err := New(
+		WithConcurrency(8),
+		WithHostPolicy(limit.DefaultPolicy),
+		WithHostRules(limit.Rule{Pattern: "koroutine.tech", Policy: limit.Policy{RequestsPerSecond: 100, Burst: 1, MaxConcurrent: 1}}),
+	)
+	assert.NoError(t, err)
+	start := time.Now()
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 10)
+	assert.Equal(t, int32(1), atomic.LoadInt32(maxInFlight))
+	// 11 requests at 100 requests per second with no burst take at least 100ms:
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlMaxPages(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="/page1">Page 1</a><a href="/page2">Page 2</a>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New(WithMaxPages(1))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 2)
+
+	// The partial tree is returned, along with the budget which stopped the crawl:
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.EqualError(t, err, "budget exceeded: max pages")
+	assert.Len(t, root.Links, 2)
+	assert.Equal(t, 1, pageCallCount())
+	assert.Equal(t, BudgetMaxPages, c.Stats().StopReason)
+}
+
+/*****************************************************************************************************************/
+
+func TestLimitedReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		limit     int64
+		expected  string
+		truncated bool
+	}{
+		{name: "Shorter than the limit", body: "<p>Hi</p>", limit: 16, expected: "<p>Hi</p>"},
+		{name: "Exactly the limit", body: "<p>Hi</p>", limit: 9, expected: "<p>Hi</p>"},
+		{name: "Longer than the limit", body: "<p>Hello</p>", limit: 8, expected: "<p>Hello", truncated: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &limitedReader{Reader: strings.NewReader(tc.body), n: tc.limit}
+
+			body, err := io.ReadAll(r)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(body))
+			assert.Equal(t, tc.truncated, r.truncated)
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlMaxBodySize(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	// The second link falls beyond the limit, so is never seen:
+	home := `<a href="/large">Large</a><a href="/streamed">Streamed</a>` + strings.Repeat(" ", 64) + `<a href="/hidden">Hidden</a>`
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, home)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	// A declared length over the limit is refused before reading:
+	httpmock.RegisterResponder("GET", baseURL+"/large",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			resp.ContentLength = 1 << 30
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/streamed",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "<p>Small</p>")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithMaxBodySize(100))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.True(t, root.Truncated)
+	assert.Equal(t, int64(100), root.ContentLength)
+	assert.Empty(t, root.Error)
+	assert.Len(t, root.Links, 2)
+
+	large := root.Links[0]
+	assert.Contains(t, large.Error, "body too large: 1073741824 bytes declared, limit 100")
+	assert.Equal(t, int64(1<<30), large.ContentLength)
+	assert.False(t, large.Truncated)
+
+	streamed := root.Links[1]
+	assert.Empty(t, streamed.Error)
+	assert.False(t, streamed.Truncated)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlTranscodesCharset(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	// "Café" and "Crème" in ISO-8859-1, declared by a meta element rather than the header:
+	body := `<html><head><meta charset="iso-8859-1"><title>Caf` + "\xe9" + `</title></head>` +
+		`<body><a href="/cr` + "\xe8" + `me">Cr` + "\xe8" + `me</a></body></html>`
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, body)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/cr%C3%A8me",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "<html><body>Crème</body></html>")
+			resp.Header.Add("Content-Type", "text/html; charset=utf-8")
+			return resp, nil
+		})
+
+	c, err := New(WithHostPolicy(limit.Policy{}))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Café", root.Title)
+	assert.Equal(t, "windows-1252", root.Charset)
+	assert.Len(t, root.Links, 1)
+	assert.Equal(t, "https://koroutine.tech/cr%C3%A8me", root.Links[0].URL)
+	assert.Equal(t, "utf-8", root.Links[0].Charset)
+	assert.Empty(t, root.Links[0].Error)
+	assert.Equal(t, "Crème", c.Graph.Inlinks(root.Links[0].URL)[0].AnchorText)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlCustomParserReceivesRawBody(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	// The PNG signature, whose bytes would be mangled if decoded as windows-1252:
+	png := "\x89PNG\r\n\x1a\n\x80\xff"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, png)
+			resp.Header.Add("Content-Type", "image/png")
+			return resp, nil
+		})
+
+	var received []byte
+
+	pngParser := parse.ParserFunc(func(body io.Reader, base string) (*parse.Document, error) {
+		var err error
+
+		received, err = io.ReadAll(body)
+
+		return &parse.Document{Base: base}, err
+	})
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithParser("image/png", pngParser))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, root.Error)
+	assert.Equal(t, []byte(png), received)
+	assert.Empty(t, root.Charset)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlRespectsRobots(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL+"/robots.txt",
+		httpmock.NewStringResponder(200, "User-agent: *\nDisallow: /private\n"))
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="/page1">Page 1</a><a href="/private/page">Private</a>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/page1",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 2)
+	assert.Empty(t, root.Links[0].Skipped)
+	// The disallowed link is reported as skipped rather than dropped, and never fetched:
+	assert.Equal(t, baseURL+"/private/page", root.Links[1].URL)
+	assert.Equal(t, SkippedByRobots, root.Links[1].Skipped)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET "+baseURL+"/private/page"])
+
+	// Overriding robots.txt for the host crawls the link anyway:
+	c, err = New(WithRobotsOverrides("koroutine.tech"), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	assert.NoError(t, err)
+	root, err = c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, root.Links[1].Skipped)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlRespectsNoFollow(t *testing.T) {
+	baseURL := "https://koroutine.tech"
+
+	register := func() {
+		pages := map[string]struct {
+			body   string
+			header string
+		}{
+			baseURL: {body: `
+				<a href="/followed">Followed</a>
+				<a href="/sponsored" rel="Sponsored NoFollow">Sponsored</a>
+				<a href="/meta">Meta</a>
+				<a href="/header">Header</a>
+				<a href="/agent">Agent</a>
+			`},
+			baseURL + "/meta":   {body: `<meta name="robots" content="noindex, nofollow"><a href="/meta/child">Child</a>`},
+			baseURL + "/header": {body: `<a href="/header/child">Child</a>`, header: "noindex"},
+			baseURL + "/agent":  {body: `<meta name="koroutine-web-crawler" content="nofollow"><a href="/agent/child">Child</a>`},
+		}
+
+		for pageURL, p := range pages {
+			p := p
+
+			httpmock.RegisterResponder("GET", pageURL,
+				func(req *http.Request) (*http.Response, error) {
+					resp := httpmock.NewStringResponse(200, p.body)
+					resp.Header.Add("Content-Type", "text/html")
+
+					if p.header != "" {
+						resp.Header.Add("X-Robots-Tag", p.header)
+					}
+
+					return resp, nil
+				})
+		}
+
+		httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`/(followed|sponsored|child)$`),
+			httpmock.NewStringResponder(200, ""))
+	}
+
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	register()
+
+	c, err := New(WithHostPolicy(limit.Policy{}))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 2)
+	assert.NoError(t, err)
+
+	links := map[string]*URLNode{}
+
+	for _, link := range root.Links {
+		links[link.URL] = link
+	}
+
+	assert.Empty(t, links[baseURL+"/followed"].Skipped)
+	assert.Equal(t, SkippedByNoFollowLink, links[baseURL+"/sponsored"].Skipped)
+	assert.Zero(t, links[baseURL+"/sponsored"].StatusCode)
+
+	meta := links[baseURL+"/meta"]
+	assert.True(t, meta.NoIndex)
+	assert.True(t, meta.NoFollow)
+	assert.Equal(t, []string{"noindex", "nofollow"}, meta.Directives)
+	assert.Equal(t, SkippedByNoFollowPage, meta.Links[0].Skipped)
+
+	header := links[baseURL+"/header"]
+	assert.True(t, header.NoIndex)
+	assert.False(t, header.NoFollow)
+	assert.Empty(t, header.Links[0].Skipped)
+
+	agent := links[baseURL+"/agent"]
+	assert.True(t, agent.NoFollow)
+	assert.Equal(t, SkippedByNoFollowPage, agent.Links[0].Skipped)
+
+	// Skipped links are still part of the link graph:
+	assert.True(t, c.Graph.Has(baseURL+"/sponsored"))
+
+	// Ignoring nofollow follows every link, but still records the directives:
+	httpmock.Reset()
+
+	mockMissingRobots()
+
+	register()
+
+	c, err = New(WithoutNoFollow(), WithHostPolicy(limit.Policy{}))
+	assert.NoError(t, err)
+	root, err = c.Crawl(baseURL, 2)
+	assert.NoError(t, err)
+
+	for _, link := range root.Links {
+		assert.Empty(t, link.Skipped)
+	}
+
+	assert.Equal(t, 200, root.Links[1].StatusCode)
+	assert.True(t, root.Links[2].NoFollow)
+	assert.Empty(t, root.Links[2].Links[0].Skipped)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlContextCancelled(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="/page1">Page 1</a>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.CrawlContext(ctx, baseURL, 2)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotNil(t, root)
+	assert.Empty(t, root.Links)
+	assert.Equal(t, 0, pageCallCount())
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlContextDeadlineReturnsPartialTree(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="/slow">Slow</a>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	// The slow page blocks until the request context is done:
+	httpmock.RegisterResponder("GET", baseURL+"/slow",
+		func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	c, err := New()
+	assert.NoError(t, err)
+
+	// Drain the stream as a consumer would:
+	go func() {
+		for range c.Stream() {
+		}
+	}()
+
+	root, err := c.CrawlContext(ctx, baseURL, 2)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotNil(t, root)
+	assert.Len(t, root.Links, 1)
+	assert.Equal(t, baseURL+"/slow", root.Links[0].URL)
 }
 
 /*****************************************************************************************************************/
 
 func BenchmarkCrawler(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		c := New()
+		c, _ := New()
 
 		c.Crawl("https:/koroutine.tech", 2)
 	}
@@ -0,0 +1,39 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import "errors"
+
+/*****************************************************************************************************************/
+
+// The kinds of error returned when fetching a page. Errors returned by the crawler wrap exactly one of these,
+// so they can be told apart with errors.Is.
+var (
+	// ErrTransport is returned when no response was received, e.g., a DNS, connection or timeout error.
+	ErrTransport = errors.New("transport failure")
+
+	// ErrRedirect is returned when a redirect chain is not followed to the end, see ErrTooManyRedirects,
+	// ErrRedirectLoop and ErrRedirectOutOfScope.
+	ErrRedirect = errors.New("redirect failure")
+
+	// ErrBadStatus is returned when the response status code is not 200 OK.
+	ErrBadStatus = errors.New("unexpected status code")
+
+	// ErrBodyTooLarge is returned when the Content-Length of the response is over the maximum body size. Bodies
+	// which turn out to be larger than declared are truncated instead.
+	ErrBodyTooLarge = errors.New("body too large")
+
+	// ErrUnsupportedContentType is returned when the response is not a document the crawler can parse.
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+
+	// ErrParse is returned when the parser registered for the content type fails to parse the response.
+	ErrParse = errors.New("parse failure")
+)
+
+/*****************************************************************************************************************/
@@ -0,0 +1,125 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import "sync"
+
+/*****************************************************************************************************************/
+
+// task is a unit of work on the frontier: a URL to fetch, the node its links are attached to and its depth.
+type task struct {
+	url   string
+	node  *URLNode
+	depth int
+}
+
+/*****************************************************************************************************************/
+
+// frontier is an unbounded FIFO queue of tasks shared by the crawl workers. It keeps track of the number of
+// pending tasks (queued or in progress), and closes itself once every pushed task has been marked as done.
+type frontier struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []task
+	pending int
+	closed  bool
+}
+
+/*****************************************************************************************************************/
+
+// newFrontier creates an empty, open frontier.
+func newFrontier() *frontier {
+	f := &frontier{}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+/*****************************************************************************************************************/
+
+// push appends a task to the queue, reporting false if the frontier has already been closed.
+func (f *frontier) push(t task) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return false
+	}
+
+	f.queue = append(f.queue, t)
+	f.pending++
+	f.cond.Signal()
+
+	return true
+}
+
+/*****************************************************************************************************************/
+
+// hold keeps the frontier open without queueing a task, e.g., while seeding it. It is released with done.
+func (f *frontier) hold() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pending++
+}
+
+/*****************************************************************************************************************/
+
+// pop blocks until a task is available, returning false once the frontier has been closed.
+func (f *frontier) pop() (task, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.queue) == 0 && !f.closed {
+		f.cond.Wait()
+	}
+
+	if f.closed {
+		return task{}, false
+	}
+
+	t := f.queue[0]
+	f.queue[0] = task{} // release the node reference held by the backing array
+	f.queue = f.queue[1:]
+
+	return t, true
+}
+
+/*****************************************************************************************************************/
+
+// done marks a popped task (or a hold) as finished, closing the frontier when no pending tasks remain.
+func (f *frontier) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pending--
+
+	if f.pending <= 0 {
+		f.closed = true
+		f.cond.Broadcast()
+	}
+}
+
+/*****************************************************************************************************************/
+
+// close stops the frontier early, discarding any queued tasks and waking up all waiting workers. The discarded
+// tasks are returned, so their nodes can report why they were never fetched.
+func (f *frontier) close() []task {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	discarded := f.queue
+
+	f.closed = true
+	f.queue = nil
+	f.cond.Broadcast()
+
+	return discarded
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,95 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+func TestFrontierFIFO(t *testing.T) {
+	f := newFrontier()
+
+	assert.True(t, f.push(task{url: "https://koroutine.tech/a"}))
+	assert.True(t, f.push(task{url: "https://koroutine.tech/b"}))
+
+	first, ok := f.pop()
+	assert.True(t, ok)
+	assert.Equal(t, "https://koroutine.tech/a", first.url)
+
+	second, ok := f.pop()
+	assert.True(t, ok)
+	assert.Equal(t, "https://koroutine.tech/b", second.url)
+}
+
+/*****************************************************************************************************************/
+
+func TestFrontierClosesWhenAllTasksDone(t *testing.T) {
+	f := newFrontier()
+
+	f.push(task{url: "https://koroutine.tech"})
+
+	_, ok := f.pop()
+	assert.True(t, ok)
+
+	// A task pushed while another is in progress keeps the frontier open:
+	f.push(task{url: "https://koroutine.tech/a"})
+	f.done()
+
+	_, ok = f.pop()
+	assert.True(t, ok)
+
+	f.done()
+
+	_, ok = f.pop()
+	assert.False(t, ok)
+	assert.False(t, f.push(task{url: "https://koroutine.tech/b"}))
+}
+
+/*****************************************************************************************************************/
+
+func TestFrontierCloseDiscardsQueuedTasks(t *testing.T) {
+	f := newFrontier()
+
+	f.push(task{url: "https://koroutine.tech/a"})
+	f.push(task{url: "https://koroutine.tech/b"})
+
+	discarded := f.close()
+
+	assert.Len(t, discarded, 2)
+	assert.Equal(t, "https://koroutine.tech/a", discarded[0].url)
+
+	_, ok := f.pop()
+	assert.False(t, ok)
+}
+
+/*****************************************************************************************************************/
+
+func TestFrontierHold(t *testing.T) {
+	f := newFrontier()
+
+	f.hold()
+
+	// With nothing queued, the hold keeps the frontier open until it is released:
+	popped := make(chan bool)
+
+	go func() {
+		_, ok := f.pop()
+		popped <- ok
+	}()
+
+	f.done()
+
+	assert.False(t, <-popped)
+}
+
+/*****************************************************************************************************************/
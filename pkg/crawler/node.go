@@ -0,0 +1,188 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	parse "github.com/michealroberts/koroutine-web-crawler/pkg/parsers"
+	"github.com/michealroberts/koroutine-web-crawler/pkg/robots"
+)
+
+/*****************************************************************************************************************/
+
+// Reasons reported in URLNode.Skipped for links which were discovered but deliberately not crawled.
+const (
+	SkippedByRobots       = "robots.txt"
+	SkippedOutOfScope     = "out of scope"
+	SkippedByNoFollowLink = "rel=nofollow"
+	SkippedByNoFollowPage = "nofollow"
+)
+
+/*****************************************************************************************************************/
+
+// Sources reported in URLNode.Source for URLs which were not discovered by following links.
+const (
+	SourceSitemap = "sitemap"
+)
+
+/*****************************************************************************************************************/
+
+type URLNode struct {
+	URL     string     `json:"url"`
+	Links   []*URLNode `json:"links"`
+	Skipped string     `json:"skipped,omitempty"` // why the URL was not crawled, empty when it was
+	Source  string     `json:"source,omitempty"`  // where the URL was found, empty for links
+	Depth   int        `json:"depth"`
+
+	// Fetch metadata, set once the URL has been fetched:
+	StatusCode     int                   `json:"status_code,omitempty"`
+	FinalURL       string                `json:"final_url,omitempty"` // the URL after following any redirects
+	Redirects      []*Redirect           `json:"redirects,omitempty"` // every hop of the redirect chain, in order
+	ContentType    string                `json:"content_type,omitempty"`
+	Charset        string                `json:"charset,omitempty"`        // the charset the body was decoded from
+	ContentLength  int64                 `json:"content_length,omitempty"` // bytes downloaded, or the declared length if unread
+	Truncated      bool                  `json:"truncated,omitempty"`      // the body was cut off at the maximum body size
+	ResponseTimeMs int64                 `json:"response_time_ms,omitempty"`
+	FetchedAt      *time.Time            `json:"fetched_at,omitempty"`
+	Error          string                `json:"error,omitempty"`
+	Attempts       int                   `json:"attempts,omitempty"` // the number of times the URL was fetched, including retries
+	Title          string                `json:"title,omitempty"`
+	NoIndex        bool                  `json:"noindex,omitempty"`         // the page asked not to be indexed
+	NoFollow       bool                  `json:"nofollow,omitempty"`        // the page asked for its links not to be followed
+	Directives     []string              `json:"directives,omitempty"`      // meta robots and X-Robots-Tag directives
+	Resources      []*Resource           `json:"resources,omitempty"`       // images, scripts, stylesheets, etc. loaded by the page
+	Meta           *parse.PageMeta       `json:"meta,omitempty"`            // description, OpenGraph tags, outline, etc. of HTML pages
+	StructuredData *parse.StructuredData `json:"structured_data,omitempty"` // JSON-LD and microdata of HTML pages
+	Feeds          []string              `json:"feeds,omitempty"`           // the RSS and Atom feeds advertised by the page
+	Entries        []*FeedEntry          `json:"entries,omitempty"`         // the items of a feed
+}
+
+/*****************************************************************************************************************/
+
+// FeedEntry is an item of an RSS or Atom feed.
+type FeedEntry struct {
+	URL       string     `json:"url"`
+	Title     string     `json:"title,omitempty"`
+	Published *time.Time `json:"published,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// page is the outcome of fetching a single URL, recorded onto its node once the fetch completes.
+type page struct {
+	statusCode    int
+	finalURL      string
+	redirects     []*Redirect
+	contentType   string
+	charset       string
+	contentLength int64
+	truncated     bool
+	bytesRead     int64 // the bytes of the body actually downloaded, charged against the byte budget
+	fetchedAt     time.Time
+	responseTime  time.Duration
+	retryAfter    *time.Duration // the delay asked for by a Retry-After header, if any
+	links         []parse.Link
+	resources     []parse.Link
+	directives    robots.Directives
+	feeds         []string
+	meta          *parse.PageMeta
+	structured    *parse.StructuredData
+	entries       []parse.FeedItem
+	title         string
+}
+
+/*****************************************************************************************************************/
+
+// record copies the outcome of the last attempt at a fetch onto the node. The page is nil when no response was
+// received.
+func (c *Crawler) record(node *URLNode, p *page, attempts int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	node.Attempts = attempts
+
+	if err != nil {
+		node.Error = err.Error()
+	}
+
+	if p == nil {
+		return
+	}
+
+	fetchedAt := p.fetchedAt
+
+	node.StatusCode = p.statusCode
+	node.FinalURL = p.finalURL
+	node.Redirects = p.redirects
+
+	if len(p.redirects) > 0 {
+		c.redirects.Pages++
+		c.redirects.Hops += len(p.redirects)
+	}
+
+	switch {
+	case errors.Is(err, ErrRedirectLoop):
+		c.redirects.Loops++
+	case errors.Is(err, ErrTooManyRedirects):
+		c.redirects.TooMany++
+	case errors.Is(err, ErrRedirectOutOfScope):
+		c.redirects.OutOfScope++
+	}
+	node.ContentType = p.contentType
+	node.Charset = p.charset
+	node.ContentLength = p.contentLength
+	node.Truncated = p.truncated
+	node.ResponseTimeMs = p.responseTime.Milliseconds()
+	node.FetchedAt = &fetchedAt
+	node.Title = p.title
+	node.NoIndex = p.directives.NoIndex
+	node.NoFollow = p.directives.NoFollow
+	node.Directives = p.directives.Values
+
+	node.Meta = p.meta
+	node.StructuredData = p.structured
+
+	if p.structured != nil {
+		for _, t := range p.structured.Types() {
+			c.types[t]++
+		}
+	}
+	node.Feeds = p.feeds
+
+	for _, item := range p.entries {
+		entry := &FeedEntry{URL: item.URL, Title: item.Title}
+
+		if !item.Published.IsZero() {
+			published := item.Published
+			entry.Published = &published
+		}
+
+		node.Entries = append(node.Entries, entry)
+	}
+
+	for _, link := range p.resources {
+		node.Resources = append(node.Resources, &Resource{URL: link.URL, Element: link.Element, Attribute: link.Attribute})
+	}
+}
+
+/*****************************************************************************************************************/
+
+// MarshalTree encodes the tree crawled so far as JSON. The workers fill in the nodes as their pages are fetched, so
+// the tree is only safe to read directly once the crawl has completed, while this may be called at any time.
+func (c *Crawler) MarshalTree() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return json.Marshal(c.Root)
+}
+
+/*****************************************************************************************************************/
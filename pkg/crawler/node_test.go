@@ -0,0 +1,135 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+func TestRecordPage(t *testing.T) {
+	c, err := New()
+	assert.NoError(t, err)
+
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	node := &URLNode{URL: "https://koroutine.tech", Depth: 1}
+
+	c.record(node, &page{
+		statusCode:    200,
+		finalURL:      "https://koroutine.tech/",
+		contentType:   "text/html",
+		contentLength: 1024,
+		fetchedAt:     fetchedAt,
+		responseTime:  1500 * time.Microsecond,
+		title:         "Koroutine",
+	}, 1, nil)
+
+	data, err := json.Marshal(node)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"url": "https://koroutine.tech",
+		"links": null,
+		"depth": 1,
+		"status_code": 200,
+		"final_url": "https://koroutine.tech/",
+		"content_type": "text/html",
+		"content_length": 1024,
+		"response_time_ms": 1,
+		"fetched_at": "2024-05-01T12:00:00Z",
+		"attempts": 1,
+		"title": "Koroutine"
+	}`, string(data))
+}
+
+/*****************************************************************************************************************/
+
+func TestRecordTransportError(t *testing.T) {
+	c, err := New()
+	assert.NoError(t, err)
+
+	node := &URLNode{URL: "https://koroutine.tech"}
+
+	c.record(node, nil, 3, errors.New("transport failure: connection refused"))
+
+	data, err := json.Marshal(node)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"url": "https://koroutine.tech",
+		"links": null,
+		"depth": 0,
+		"error": "transport failure: connection refused",
+		"attempts": 3
+	}`, string(data))
+}
+
+/*****************************************************************************************************************/
+
+func TestMarshalTreeWhileCrawling(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", `=~^https://koroutine\.tech`,
+		func(req *http.Request) (*http.Response, error) {
+			body := ""
+
+			for i := range 5 {
+				body += fmt.Sprintf(`<a href="%s/%d">%d</a>`, req.URL.Path, i, i)
+			}
+
+			resp := httpmock.NewStringResponse(200, body)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithConcurrency(4))
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+
+	// Encode the tree on every streamed node, as the API does, while the workers are still filling it in:
+	go func() {
+		defer close(done)
+
+		for range c.Stream() {
+			_, err := c.MarshalTree()
+			assert.NoError(t, err)
+		}
+	}()
+
+	_, err = c.Crawl(baseURL, 2)
+	assert.NoError(t, err)
+
+	<-done
+
+	data, err := c.MarshalTree()
+	assert.NoError(t, err)
+
+	var root URLNode
+
+	assert.NoError(t, json.Unmarshal(data, &root))
+	assert.Equal(t, 200, root.StatusCode)
+	assert.Len(t, root.Links, 5)
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,155 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+/*****************************************************************************************************************/
+
+// DefaultMaxRedirects is the number of redirects followed for a single page, the same as the net/http default.
+const DefaultMaxRedirects = 10
+
+/*****************************************************************************************************************/
+
+// The reasons a redirect chain is not followed to the end, wrapped alongside ErrRedirect.
+var (
+	// ErrTooManyRedirects is returned when a chain is longer than the configured maximum number of hops.
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	// ErrRedirectLoop is returned when a chain redirects back to a URL it has already visited.
+	ErrRedirectLoop = errors.New("redirect loop")
+
+	// ErrRedirectOutOfScope is returned when a page redirects out of scope, unless off-scope redirects are
+	// followed.
+	ErrRedirectOutOfScope = errors.New("redirect out of scope")
+)
+
+/*****************************************************************************************************************/
+
+// Redirect is a single hop of a redirect chain.
+type Redirect struct {
+	URL        string `json:"url"`         // the URL which redirected
+	StatusCode int    `json:"status_code"` // e.g., 301 Moved Permanently
+	Location   string `json:"location"`    // the Location header, as sent by the server
+}
+
+/*****************************************************************************************************************/
+
+// RedirectStats counts the redirect chains of a crawl.
+type RedirectStats struct {
+	Pages      int `json:"pages"`        // pages reached through at least one redirect
+	Hops       int `json:"hops"`         // redirects followed across all pages
+	Loops      int `json:"loops"`        // chains stopped by a redirect loop
+	TooMany    int `json:"too_many"`     // chains stopped after the maximum number of redirects
+	OutOfScope int `json:"out_of_scope"` // chains stopped by a redirect out of scope
+}
+
+/*****************************************************************************************************************/
+
+// redirectTrace collects the redirect chain of a page fetch, as followed by the client.
+type redirectTrace struct {
+	hops  []*Redirect
+	final *url.URL // the URL of the last request made, after following the chain
+	err   error    // why the chain was not followed to the end, if it was not
+}
+
+/*****************************************************************************************************************/
+
+// redirectTraceKey is the context key of the redirect trace of a request.
+type redirectTraceKey struct{}
+
+/*****************************************************************************************************************/
+
+// withRedirectTrace returns a context which collects the redirect chain of the requests made with it.
+func withRedirectTrace(ctx context.Context) (context.Context, *redirectTrace) {
+	trace := &redirectTrace{}
+
+	return context.WithValue(ctx, redirectTraceKey{}, trace), trace
+}
+
+/*****************************************************************************************************************/
+
+// checkRedirect is the CheckRedirect function of the crawler's client, wrapping any set on the configured client.
+// Every hop is recorded on the trace of the request, if any, and chains which are too long or loop are stopped.
+// Page fetches, which carry a trace, also stop at redirects out of scope, and return the redirect response itself
+// so the chain so far can be reported. When redirects are disabled, page fetches return the redirect response as
+// any other, while robots.txt, sitemaps and resources still follow up to DefaultMaxRedirects.
+func (c *Crawler) checkRedirect(next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		trace, _ := req.Context().Value(redirectTraceKey{}).(*redirectTrace)
+
+		if trace != nil && *c.config.MaxRedirects == 0 {
+			return http.ErrUseLastResponse
+		}
+
+		err := c.redirectError(req, via, trace != nil)
+
+		if err == nil && next != nil {
+			err = next(req, via)
+		}
+
+		// A redirect the configured client does not follow is a response like any other:
+		if trace == nil || errors.Is(err, http.ErrUseLastResponse) {
+			return err
+		}
+
+		trace.hops = append(trace.hops, &Redirect{
+			URL:        via[len(via)-1].URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.Response.Header.Get("Location"),
+		})
+
+		if err != nil {
+			trace.err = err
+			return http.ErrUseLastResponse
+		}
+
+		trace.final = req.URL
+
+		return nil
+	}
+}
+
+/*****************************************************************************************************************/
+
+// redirectError reports why a redirect to the request's URL should not be followed, or nil if it should be.
+func (c *Crawler) redirectError(req *http.Request, via []*http.Request, page bool) error {
+	// Loops compare exact URLs, as canonically equal URLs redirecting to each other, e.g., http:// to https:// or
+	// adding a trailing slash, are how most sites normalise their URLs:
+	for _, previous := range via {
+		if previous.URL.String() == req.URL.String() {
+			return fmt.Errorf("%w back to %s", ErrRedirectLoop, req.URL)
+		}
+	}
+
+	maxRedirects := *c.config.MaxRedirects
+
+	// Only page fetches are left unfollowed when redirects are disabled:
+	if maxRedirects == 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+
+	if len(via) > maxRedirects {
+		return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, maxRedirects)
+	}
+
+	if page && !c.config.FollowOffScopeRedirects && !c.inScope(req.URL) {
+		return fmt.Errorf("%w to %s", ErrRedirectOutOfScope, req.URL)
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,198 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+// redirectTo responds with a redirect to the location.
+func redirectTo(status int, location string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(status, "")
+		resp.Header.Add("Location", location)
+		return resp, nil
+	}
+}
+
+/*****************************************************************************************************************/
+
+// htmlPage responds with an HTML document.
+func htmlPage(body string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(200, body)
+		resp.Header.Add("Content-Type", "text/html")
+		return resp, nil
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlRecordsRedirectChain(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		htmlPage(`<a href="/old">Old</a><a href="/new/">New</a>`))
+
+	httpmock.RegisterResponder("GET", baseURL+"/old", redirectTo(http.StatusMovedPermanently, "/older"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/older", redirectTo(http.StatusFound, "https://koroutine.tech/new/"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/new/", htmlPage(`<p>New</p>`))
+
+	// The link to /new/ is only queued once /old has been followed to it:
+	c, err := New(WithHostPolicy(limit.Policy{}), WithConcurrency(1))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 2)
+
+	old := root.Links[0]
+	assert.Empty(t, old.Error)
+	assert.Equal(t, 200, old.StatusCode)
+	assert.Equal(t, baseURL+"/new/", old.FinalURL)
+	assert.Equal(t, []*Redirect{
+		{URL: baseURL + "/old", StatusCode: 301, Location: "/older"},
+		{URL: baseURL + "/older", StatusCode: 302, Location: "https://koroutine.tech/new/"},
+	}, old.Redirects)
+
+	// The final URL was marked as visited, so it is not fetched a second time:
+	assert.Equal(t, 0, root.Links[1].StatusCode)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/new/"])
+
+	stats := c.Stats()
+	assert.Equal(t, RedirectStats{Pages: 1, Hops: 2}, stats.Redirects)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlReportsRedirectFailures(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		htmlPage(`<a href="/loop">Loop</a><a href="/chain/0">Chain</a><a href="/away">Away</a>`))
+
+	httpmock.RegisterResponder("GET", baseURL+"/loop", redirectTo(http.StatusFound, "/loop/back"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/loop/back", redirectTo(http.StatusFound, "/loop"))
+
+	for i := range 5 {
+		httpmock.RegisterResponder("GET", fmt.Sprintf("%s/chain/%d", baseURL, i),
+			redirectTo(http.StatusMovedPermanently, fmt.Sprintf("/chain/%d", i+1)))
+	}
+
+	httpmock.RegisterResponder("GET", baseURL+"/away", redirectTo(http.StatusFound, "https://example.com/login"))
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithMaxRedirects(3))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 3)
+
+	loop := root.Links[0]
+	assert.Contains(t, loop.Error, "redirect loop back to https://koroutine.tech/loop")
+	assert.Equal(t, 302, loop.StatusCode)
+	assert.Len(t, loop.Redirects, 2)
+
+	chain := root.Links[1]
+	assert.Contains(t, chain.Error, "too many redirects: stopped after 3")
+	assert.Equal(t, 301, chain.StatusCode)
+	assert.Len(t, chain.Redirects, 4)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET "+baseURL+"/chain/4"])
+
+	// The off-scope target is reported rather than fetched:
+	away := root.Links[2]
+	assert.Contains(t, away.Error, "redirect out of scope to https://example.com/login")
+	assert.Equal(t, 302, away.StatusCode)
+	assert.Equal(t, []*Redirect{{URL: baseURL + "/away", StatusCode: 302, Location: "https://example.com/login"}}, away.Redirects)
+
+	// Redirect failures are final, and not retried:
+	assert.Equal(t, 1, away.Attempts)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/away"])
+
+	assert.Equal(t, RedirectStats{Pages: 3, Hops: 7, Loops: 1, TooMany: 1, OutOfScope: 1}, c.Stats().Redirects)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlWithOffScopeRedirects(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL, redirectTo(http.StatusFound, "https://example.com/login"))
+
+	httpmock.RegisterResponder("GET", "https://example.com/login",
+		htmlPage(`<a href="/signup">Sign up</a><a href="https://koroutine.tech/help">Help</a>`))
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithOffScopeRedirects(), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, root.Error)
+	assert.Equal(t, "https://example.com/login", root.FinalURL)
+
+	// Links of the off-scope page are still only followed when in scope:
+	assert.Len(t, root.Links, 1)
+	assert.Equal(t, baseURL+"/help", root.Links[0].URL)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlWithoutFollowingRedirects(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL, htmlPage(`<a href="/old">Old</a>`))
+
+	httpmock.RegisterResponder("GET", baseURL+"/old", redirectTo(http.StatusMovedPermanently, "/new"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/new", htmlPage(`<p>New</p>`))
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithMaxRedirects(0))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, *c.config.MaxRedirects)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, root.Links, 1)
+
+	// The redirect response is returned itself, rather than being followed:
+	old := root.Links[0]
+	assert.Equal(t, 301, old.StatusCode)
+	assert.Equal(t, baseURL+"/old", old.FinalURL)
+	assert.Empty(t, old.Redirects)
+	assert.Contains(t, old.Error, "301")
+	assert.Equal(t, 1, old.Attempts)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET "+baseURL+"/new"])
+
+	assert.Equal(t, RedirectStats{}, c.Stats().Redirects)
+}
+
+/*****************************************************************************************************************/
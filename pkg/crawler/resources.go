@@ -0,0 +1,137 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+/*****************************************************************************************************************/
+
+// Resource is a resource loaded by a page, e.g., an image, script or stylesheet. Resources are never crawled,
+// but with Config.CheckResources each in-scope resource is checked with a HEAD request.
+type Resource struct {
+	URL         string `json:"url"`
+	Element     string `json:"element"`   // the tag the URL was found in, e.g., "img"
+	Attribute   string `json:"attribute"` // the attribute the URL was found in, e.g., "srcset"
+	Skipped     string `json:"skipped,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// resourceCheck is the outcome of checking a resource, shared by every page linking to it.
+type resourceCheck struct {
+	once        sync.Once
+	statusCode  int
+	contentType string
+	err         error
+}
+
+/*****************************************************************************************************************/
+
+// checkResources checks the resources of a page, checking each distinct resource only once per crawl.
+func (c *Crawler) checkResources(ctx context.Context, node *URLNode) {
+	c.mu.Lock()
+	resources := node.Resources
+	c.mu.Unlock()
+
+	for _, resource := range resources {
+		if ctx.Err() != nil {
+			return
+		}
+
+		link, err := url.Parse(resource.URL)
+
+		if err != nil {
+			continue
+		}
+
+		if !c.inScope(link) {
+			c.mu.Lock()
+			resource.Skipped = SkippedOutOfScope
+			c.mu.Unlock()
+			continue
+		}
+
+		if skipped := c.skipReason(ctx, link); skipped != "" {
+			c.mu.Lock()
+			resource.Skipped = skipped
+			c.mu.Unlock()
+			continue
+		}
+
+		check := c.resourceCheck(c.key(link))
+
+		// Concurrent pages linking to the same resource wait for the first check instead of repeating it:
+		check.once.Do(func() {
+			check.statusCode, check.contentType, check.err = c.head(ctx, resource.URL)
+		})
+
+		c.mu.Lock()
+		resource.StatusCode = check.statusCode
+		resource.ContentType = check.contentType
+
+		if check.err != nil {
+			resource.Error = check.err.Error()
+		}
+		c.mu.Unlock()
+	}
+}
+
+/*****************************************************************************************************************/
+
+// resourceCheck returns the check of a resource by its canonical URL, creating it on first use.
+func (c *Crawler) resourceCheck(key string) *resourceCheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	check, ok := c.checked[key]
+
+	if !ok {
+		check = &resourceCheck{}
+		c.checked[key] = check
+	}
+
+	return check
+}
+
+/*****************************************************************************************************************/
+
+// head checks that a resource exists with a HEAD request, falling back to GET for servers which do not support
+// HEAD. Errors wrap ErrTransport or ErrBadStatus.
+func (c *Crawler) head(ctx context.Context, urlStr string) (int, string, error) {
+	resp, _, err := c.request(ctx, http.MethodHead, urlStr)
+
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, _, err = c.request(ctx, http.MethodGet, urlStr)
+	}
+
+	if err != nil {
+		return 0, "", fmt.Errorf("%w: %w", ErrTransport, err)
+	}
+
+	// Only the headers are needed, so the body is never read:
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, resp.Header.Get("Content-Type"), fmt.Errorf("%w: %d", ErrBadStatus, resp.StatusCode)
+	}
+
+	return resp.StatusCode, resp.Header.Get("Content-Type"), nil
+}
+
+/*****************************************************************************************************************/
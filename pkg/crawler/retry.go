@@ -0,0 +1,167 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+// RetryPolicy describes how fetches failing with a transient error, i.e., a transport error, 429 Too Many Requests
+// or a 5xx status code, are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a page is fetched before giving up, including the first (1 for no retries).
+	MaxAttempts int `json:"max_attempts"`
+	// BaseDelay is the backoff before the first retry, doubling for every retry after it.
+	BaseDelay time.Duration `json:"base_delay"`
+	// MaxDelay caps the backoff. A Retry-After header asking for a longer wait is not retried at all.
+	MaxDelay time.Duration `json:"max_delay"`
+}
+
+/*****************************************************************************************************************/
+
+// DefaultRetryPolicy retries a failing page twice, after around one and then two seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+/*****************************************************************************************************************/
+
+// Validate reports whether the policy values are usable.
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return errors.New("max attempts must be at least 1")
+	}
+
+	if p.BaseDelay < 0 {
+		return errors.New("base delay must not be negative")
+	}
+
+	if p.MaxDelay < p.BaseDelay {
+		return errors.New("max delay must not be less than the base delay")
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// backoff returns the delay before a retry, given the number of attempts made so far. The exponential delay is
+// jittered between half and all of its value, so pages failing together are not retried together.
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	delay := p.MaxDelay
+
+	// Doubling stops at the cap, before the shift can overflow:
+	if shift := attempts - 1; shift < 32 && p.BaseDelay<<shift < p.MaxDelay {
+		delay = p.BaseDelay << shift
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+/*****************************************************************************************************************/
+
+// parseRetryAfter parses a Retry-After header, given either as a number of seconds or as an HTTP-date, into the
+// delay it asks for, reporting false when the header is missing or malformed.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+
+	if err != nil {
+		return 0, false
+	}
+
+	// A date in the past allows an immediate retry:
+	return max(date.Sub(now), 0), true
+}
+
+/*****************************************************************************************************************/
+
+// retryable checks if a failed fetch may succeed when retried, i.e., no response was received, or the server was
+// overloaded or failed.
+func retryable(p *page, err error) bool {
+	switch {
+	case errors.Is(err, ErrTransport):
+		return true
+	case errors.Is(err, ErrBadStatus):
+		return p.statusCode == http.StatusTooManyRequests || p.statusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+/*****************************************************************************************************************/
+
+// fetch fetches and parses a page with fetchAndParse, retrying transient failures with exponential backoff, or
+// after the delay asked for by a Retry-After header, until the context is done or a budget stops the crawl. It
+// returns the outcome of the last attempt alongside the number of attempts made.
+func (c *Crawler) fetch(ctx context.Context, urlStr string) (*page, int, error) {
+	policy := c.config.Retry
+
+	for attempts := 1; ; attempts++ {
+		p, err := c.fetchAndParse(ctx, urlStr)
+
+		// Cancellation surfaces as a transport error, which must not be retried, and nor is a page once the crawl has
+		// been stopped:
+		if err == nil || attempts >= policy.MaxAttempts || ctx.Err() != nil || c.stopped() || !retryable(p, err) {
+			return p, attempts, err
+		}
+
+		delay := policy.backoff(attempts)
+
+		if p != nil && p.retryAfter != nil {
+			// Retrying before the server asked us to would be impolite, so a longer wait than we allow is final:
+			if *p.retryAfter > policy.MaxDelay {
+				return p, attempts, err
+			}
+
+			delay = *p.retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return p, attempts, err
+		case <-c.halt:
+			timer.Stop()
+			return p, attempts, err
+		case <-timer.C:
+		}
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,235 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+// fastRetries retries quickly, so tests are not slowed down by the backoff.
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+/*****************************************************************************************************************/
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: 100 * time.Millisecond},
+		{attempts: 2, expected: 200 * time.Millisecond},
+		{attempts: 3, expected: 400 * time.Millisecond},
+		{attempts: 5, expected: time.Second},
+		{attempts: 100, expected: time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("Attempt %d", tc.attempts), func(t *testing.T) {
+			for range 20 {
+				delay := policy.backoff(tc.attempts)
+
+				// The jitter keeps the delay between half and all of the exponential delay:
+				assert.GreaterOrEqual(t, delay, tc.expected/2)
+				assert.LessOrEqual(t, delay, tc.expected)
+			}
+		})
+	}
+
+	assert.Equal(t, time.Duration(0), RetryPolicy{MaxAttempts: 2}.backoff(1))
+}
+
+/*****************************************************************************************************************/
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "Missing", value: "", ok: false},
+		{name: "Seconds", value: "120", expected: 2 * time.Minute, ok: true},
+		{name: "Seconds with whitespace", value: " 5 ", expected: 5 * time.Second, ok: true},
+		{name: "Negative seconds", value: "-1", ok: false},
+		{name: "HTTP-date", value: "Wed, 01 May 2024 12:00:30 GMT", expected: 30 * time.Second, ok: true},
+		{name: "HTTP-date in the past", value: "Wed, 01 May 2024 11:00:00 GMT", expected: 0, ok: true},
+		{name: "Malformed", value: "soon", ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tc.value, now)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, delay)
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, retryable(nil, fmt.Errorf("%w: connection reset", ErrTransport)))
+	assert.True(t, retryable(&page{statusCode: 429}, fmt.Errorf("%w: 429", ErrBadStatus)))
+	assert.True(t, retryable(&page{statusCode: 503}, fmt.Errorf("%w: 503", ErrBadStatus)))
+	assert.False(t, retryable(&page{statusCode: 404}, fmt.Errorf("%w: 404", ErrBadStatus)))
+	assert.False(t, retryable(&page{statusCode: 200}, fmt.Errorf("%w: \"application/pdf\"", ErrUnsupportedContentType)))
+	assert.False(t, retryable(&page{statusCode: 200}, fmt.Errorf("%w: unexpected EOF", ErrParse)))
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlRetriesTransientFailures(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	// The first request fails, and the second succeeds:
+	httpmock.RegisterResponder("GET", baseURL,
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			httpmock.NewStringResponse(503, "Service Unavailable"),
+			httpmock.NewStringResponse(200, `<a href="/missing">Missing</a><a href="/down">Down</a><a href="/reset">Reset</a>`),
+		}))
+
+	httpmock.RegisterResponder("GET", baseURL+"/missing",
+		httpmock.NewStringResponder(404, "Not Found"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/down",
+		httpmock.NewStringResponder(500, "Internal Server Error"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/reset",
+		httpmock.NewErrorResponder(fmt.Errorf("connection reset")))
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithRetryPolicy(fastRetries))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, root.StatusCode)
+	assert.Equal(t, 2, root.Attempts)
+	assert.Empty(t, root.Error)
+	assert.Len(t, root.Links, 3)
+
+	// Client errors are not retried:
+	assert.Equal(t, 1, root.Links[0].Attempts)
+	assert.Equal(t, 404, root.Links[0].StatusCode)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/missing"])
+
+	// Server and transport errors are retried up to the maximum, keeping the final error:
+	assert.Equal(t, 3, root.Links[1].Attempts)
+	assert.Equal(t, 500, root.Links[1].StatusCode)
+	assert.Contains(t, root.Links[1].Error, "500")
+	assert.Equal(t, 3, httpmock.GetCallCountInfo()["GET "+baseURL+"/down"])
+
+	assert.Equal(t, 3, root.Links[2].Attempts)
+	assert.Contains(t, root.Links[2].Error, "connection reset")
+	assert.Equal(t, 3, httpmock.GetCallCountInfo()["GET "+baseURL+"/reset"])
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlHonoursRetryAfter(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	throttled := httpmock.NewStringResponse(429, "Too Many Requests")
+	throttled.Header.Set("Retry-After", "1")
+
+	httpmock.RegisterResponder("GET", baseURL,
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			throttled,
+			httpmock.NewStringResponse(200, `<a href="/later">Later</a>`),
+		}))
+
+	// A wait longer than the maximum delay is not retried at all:
+	unavailable := httpmock.NewStringResponse(503, "Service Unavailable")
+	unavailable.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+
+	httpmock.RegisterResponder("GET", baseURL+"/later", httpmock.ResponderFromResponse(unavailable))
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    2 * time.Second,
+	}))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, root.Attempts)
+	assert.Equal(t, 200, root.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+
+	assert.Len(t, root.Links, 1)
+	assert.Equal(t, 1, root.Links[0].Attempts)
+	assert.Equal(t, 503, root.Links[0].StatusCode)
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlStopsRetryingOnceStopped(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL, htmlPage(`<a href="/busy">Busy</a><a href="/large">Large</a>`))
+
+	httpmock.RegisterResponder("GET", baseURL+"/busy",
+		httpmock.NewStringResponder(503, "Service Unavailable"))
+
+	// The large page spends the byte budget while the busy page is waiting to be retried:
+	httpmock.RegisterResponder("GET", baseURL+"/large",
+		func(req *http.Request) (*http.Response, error) {
+			time.Sleep(100 * time.Millisecond)
+
+			resp := httpmock.NewStringResponse(200, strings.Repeat(" ", 200))
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New(WithHostPolicy(limit.Policy{}), WithConcurrency(2), WithMaxBytes(100), WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Second,
+		MaxDelay:    10 * time.Second,
+	}))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	assert.Len(t, root.Links, 2)
+	assert.Equal(t, 1, root.Links[0].Attempts)
+	assert.Equal(t, 503, root.Links[0].StatusCode)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/busy"])
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,132 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	parse "github.com/michealroberts/koroutine-web-crawler/pkg/parsers"
+)
+
+/*****************************************************************************************************************/
+
+// maxSitemaps is the maximum number of sitemap documents fetched per crawl, guarding against index loops.
+const maxSitemaps = 100
+
+/*****************************************************************************************************************/
+
+// seedFromSitemaps discovers the sitemaps of the start URL's host, and schedules every in-scope page they list
+// as an additional depth 0 seed, attached to the root node. Sitemaps are only fetched when they are in scope and
+// allowed by robots.txt themselves, and no longer once a budget has stopped the crawl.
+func (c *Crawler) seedFromSitemaps(ctx context.Context, root *URLNode, start *url.URL) {
+	// Sitemaps listed in robots.txt come first, followed by the conventional location:
+	queue := append([]string(nil), c.robots.Get(ctx, start).Sitemaps...)
+
+	queue = append(queue, (&url.URL{Scheme: start.Scheme, Host: start.Host, Path: "/sitemap.xml"}).String())
+
+	fetched := make(map[string]bool)
+
+	seeded := map[string]bool{c.key(start): true}
+
+	for len(queue) > 0 && len(fetched) < maxSitemaps && ctx.Err() == nil && !c.stopped() {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+
+		if fetched[sitemapURL] {
+			continue
+		}
+
+		fetched[sitemapURL] = true
+
+		// robots.txt and sitemap indexes may list sitemaps anywhere, which must not lead the crawl off the site:
+		if link, err := url.Parse(sitemapURL); err != nil || !c.inScope(link) || !c.robotsAllowed(ctx, link) {
+			continue
+		}
+
+		sitemap, err := c.fetchSitemap(ctx, sitemapURL)
+
+		if err != nil {
+			continue
+		}
+
+		// Indexes list further sitemaps to fetch:
+		queue = append(queue, sitemap.Sitemaps...)
+
+		for _, entry := range sitemap.URLs {
+			link, err := url.Parse(entry.Loc)
+
+			if err != nil || !c.inScope(link) || seeded[c.key(link)] {
+				continue
+			}
+
+			seeded[c.key(link)] = true
+
+			c.seed(ctx, root, link)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+// seed attaches a URL found outside of the link structure to the root node, and schedules it at depth 0.
+func (c *Crawler) seed(ctx context.Context, root *URLNode, link *url.URL) {
+	node := &URLNode{URL: link.String(), Source: SourceSitemap, Skipped: c.skipReason(ctx, link), Depth: 0}
+
+	c.Graph.AddNode(c.key(link))
+
+	c.mu.Lock()
+	root.Links = append(root.Links, node)
+	c.mu.Unlock()
+
+	select {
+	case c.stream <- node:
+	case <-ctx.Done():
+		return
+	}
+
+	// A page seeded once a budget has stopped the crawl is skipped like those which were already queued:
+	if node.Skipped == "" && !c.frontier.push(task{url: node.URL, node: node, depth: 0}) {
+		c.mu.Lock()
+		node.Skipped = c.stopReason
+		c.mu.Unlock()
+	}
+}
+
+/*****************************************************************************************************************/
+
+// fetchSitemap retrieves and parses a (possibly gzipped) sitemap or sitemap index. Like pages, sitemaps are read up
+// to the maximum body size, and their bytes are counted against the byte budget.
+func (c *Crawler) fetchSitemap(ctx context.Context, sitemapURL string) (*parse.Sitemap, error) {
+	resp, _, err := c.get(ctx, sitemapURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTransport, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", ErrBadStatus, resp.StatusCode)
+	}
+
+	if resp.ContentLength > c.config.MaxBodySize {
+		return nil, fmt.Errorf("%w: %d bytes declared, limit %d", ErrBodyTooLarge, resp.ContentLength, c.config.MaxBodySize)
+	}
+
+	counter := &countingReader{Reader: resp.Body}
+
+	defer func() { c.spendBytes(counter.n) }()
+
+	return parse.SitemapFromXML(&limitedReader{Reader: counter, n: c.config.MaxBodySize})
+}
+
+/*****************************************************************************************************************/
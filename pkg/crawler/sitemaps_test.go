@@ -0,0 +1,154 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+func TestCrawlSeedsFromSitemaps(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL+"/robots.txt",
+		httpmock.NewStringResponder(200, "User-agent: *\nDisallow: /private\nSitemap: https://koroutine.tech/sitemap-index.xml\n"))
+
+	httpmock.RegisterResponder("GET", baseURL+"/sitemap-index.xml",
+		httpmock.NewStringResponder(200, `<sitemapindex><sitemap><loc>https://koroutine.tech/posts.xml.gz</loc></sitemap></sitemapindex>`))
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`<urlset><url><loc>https://koroutine.tech/orphan</loc></url><url><loc>https://koroutine.tech/private/post</loc></url></urlset>`))
+	gz.Close()
+
+	httpmock.RegisterResponder("GET", baseURL+"/posts.xml.gz",
+		httpmock.NewBytesResponder(200, buf.Bytes()))
+
+	httpmock.RegisterResponder("GET", baseURL+"/sitemap.xml",
+		httpmock.NewStringResponder(200, `<urlset><url><loc>https://koroutine.tech/orphan</loc></url><url><loc>https://external.com/page</loc></url></urlset>`))
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	httpmock.RegisterResponder("GET", baseURL+"/orphan",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New(WithSitemaps())
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	// The orphan page is seeded once despite being listed twice, the external page is out of scope:
+	assert.Len(t, root.Links, 2)
+	assert.Equal(t, baseURL+"/orphan", root.Links[0].URL)
+	assert.Equal(t, SourceSitemap, root.Links[0].Source)
+	assert.Empty(t, root.Links[0].Skipped)
+	assert.Equal(t, baseURL+"/private/post", root.Links[1].URL)
+	assert.Equal(t, SkippedByRobots, root.Links[1].Skipped)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/orphan"])
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlWithoutSitemaps(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL+"/sitemap.xml",
+		httpmock.NewStringResponder(200, `<urlset><url><loc>https://koroutine.tech/orphan</loc></url></urlset>`))
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, "")
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	c, err := New()
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, root.Links)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET "+baseURL+"/sitemap.xml"])
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlSitemapsRespectScopeAndBudgets(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL+"/robots.txt",
+		httpmock.NewStringResponder(200, "User-agent: *\nDisallow: /private\n"+
+			"Sitemap: https://example.com/sitemap.xml\n"+
+			"Sitemap: https://koroutine.tech/private/sitemap.xml\n"+
+			"Sitemap: https://koroutine.tech/index.xml\n"))
+
+	index := `<sitemapindex><sitemap><loc>https://koroutine.tech/posts.xml</loc></sitemap><sitemap><loc>https://koroutine.tech/pages.xml</loc></sitemap></sitemapindex>`
+
+	posts := `<urlset><url><loc>https://koroutine.tech/post</loc></url></urlset>`
+
+	httpmock.RegisterResponder("GET", baseURL+"/index.xml", httpmock.NewStringResponder(200, index))
+
+	httpmock.RegisterResponder("GET", baseURL+"/posts.xml", httpmock.NewStringResponder(200, posts))
+
+	httpmock.RegisterResponder("GET", baseURL+"/pages.xml", httpmock.NewStringResponder(200, `<urlset></urlset>`))
+
+	httpmock.RegisterResponder("GET", baseURL, htmlPage(""))
+
+	// The index and the first sitemap it lists use up the byte budget:
+	c, err := New(WithSitemaps(), WithHostPolicy(limit.Policy{}), WithMaxBytes(int64(len(index)+1)))
+	assert.NoError(t, err)
+	root, err := c.Crawl(baseURL, 1)
+
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+
+	// Sitemaps out of scope or disallowed by robots.txt are never fetched:
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 0, calls["GET https://example.com/sitemap.xml"])
+	assert.Equal(t, 0, calls["GET "+baseURL+"/private/sitemap.xml"])
+	assert.Equal(t, 1, calls["GET "+baseURL+"/posts.xml"])
+	assert.Equal(t, 0, calls["GET "+baseURL+"/pages.xml"])
+
+	stats := c.Stats()
+	assert.Equal(t, BudgetMaxBytes, stats.StopReason)
+	assert.Equal(t, int64(len(index)+len(posts)), stats.Bytes)
+
+	// Pages listed by the sitemap which stopped the crawl are skipped with the budget:
+	assert.Len(t, root.Links, 1)
+	assert.Equal(t, baseURL+"/post", root.Links[0].URL)
+	assert.Equal(t, BudgetMaxBytes, root.Links[0].Skipped)
+}
+
+/*****************************************************************************************************************/
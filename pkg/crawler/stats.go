@@ -0,0 +1,44 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+)
+
+/*****************************************************************************************************************/
+
+// Stats is a snapshot of the progress of a crawl.
+type Stats struct {
+	Pages      int               `json:"pages"`                 // number of pages fetched so far
+	Bytes      int64             `json:"bytes"`                 // number of bytes of page and sitemap bodies downloaded so far
+	StopReason string            `json:"stop_reason,omitempty"` // the budget which stopped the crawl, e.g., "max pages"
+	Redirects  RedirectStats     `json:"redirects"`             // redirect chains followed, and those which were stopped
+	Hosts      []limit.HostStats `json:"hosts"`                 // the limits and health of every host requested so far
+}
+
+/*****************************************************************************************************************/
+
+// Stats reports the progress of the crawl so far, including the current concurrency limit of every host, which
+// may be polled while the crawl is running.
+func (c *Crawler) Stats() Stats {
+	c.mu.Lock()
+	pages, bytes, stopReason, redirects := c.pages, c.bytes, c.stopReason, c.redirects
+	c.mu.Unlock()
+
+	return Stats{
+		Pages:      pages,
+		Bytes:      bytes,
+		StopReason: stopReason,
+		Redirects:  redirects,
+		Hosts:      c.limiter.Stats(),
+	}
+}
+
+/*****************************************************************************************************************/
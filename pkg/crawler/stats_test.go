@@ -0,0 +1,76 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	limit "github.com/michealroberts/koroutine-web-crawler/pkg/limiters"
+	"github.com/stretchr/testify/assert"
+)
+
+/*****************************************************************************************************************/
+
+func TestCrawlStatsAdaptiveThrottling(t *testing.T) {
+	activateMock()
+	defer httpmock.DeactivateAndReset()
+
+	baseURL := "https://koroutine.tech"
+
+	httpmock.RegisterResponder("GET", baseURL,
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `<a href="/a">A</a><a href="/b">B</a><a href="/busy">Busy</a>`)
+			resp.Header.Add("Content-Type", "text/html")
+			return resp, nil
+		})
+
+	for _, path := range []string{"/a", "/b"} {
+		httpmock.RegisterResponder("GET", baseURL+path,
+			func(req *http.Request) (*http.Response, error) {
+				resp := httpmock.NewStringResponse(200, "<p>OK</p>")
+				resp.Header.Add("Content-Type", "text/html")
+				return resp, nil
+			})
+	}
+
+	httpmock.RegisterResponder("GET", baseURL+"/busy",
+		httpmock.NewStringResponder(503, "Service Unavailable"))
+
+	adaptive := limit.DefaultAdaptivePolicy
+	adaptive.MinConcurrent = 2
+
+	c, err := New(
+		WithHostPolicy(limit.Policy{Adaptive: &adaptive}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+	assert.NoError(t, err)
+
+	_, err = c.Crawl(baseURL, 1)
+	assert.NoError(t, err)
+
+	stats := c.Stats()
+
+	assert.Equal(t, 4, stats.Pages)
+	assert.Len(t, stats.Hosts, 1)
+
+	host := stats.Hosts[0]
+	assert.Equal(t, "koroutine.tech", host.Host)
+	assert.True(t, host.Adaptive)
+	// The four pages, and robots.txt, which is subject to the same limits:
+	assert.Equal(t, 5, host.Requests)
+	assert.Equal(t, 1, host.Throttled)
+	assert.Equal(t, 0, host.InFlight)
+	// The 503 halves the limit, which cannot go below the minimum of 2:
+	assert.GreaterOrEqual(t, host.Limit, adaptive.MinConcurrent)
+	assert.Less(t, host.Limit, adaptive.MaxConcurrent)
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,48 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package crawler
+
+/*****************************************************************************************************************/
+
+import (
+	"sort"
+)
+
+/*****************************************************************************************************************/
+
+// TypeCount is the number of pages using a structured data type, e.g., "Product", across a crawl.
+type TypeCount struct {
+	Type  string `json:"type"`
+	Pages int    `json:"pages"`
+}
+
+/*****************************************************************************************************************/
+
+// StructuredDataTypes reports the JSON-LD and microdata types found across the crawl so far, with schema.org
+// prefixes stripped, most used first.
+func (c *Crawler) StructuredDataTypes() []TypeCount {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := make([]TypeCount, 0, len(c.types))
+
+	for t, pages := range c.types {
+		report = append(report, TypeCount{Type: t, Pages: pages})
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Pages != report[j].Pages {
+			return report[i].Pages > report[j].Pages
+		}
+
+		return report[i].Type < report[j].Type
+	})
+
+	return report
+}
+
+/*****************************************************************************************************************/
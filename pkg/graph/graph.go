@@ -0,0 +1,307 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package graph
+
+/*****************************************************************************************************************/
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+/*****************************************************************************************************************/
+
+// Edge is a directed link from one page to another, carrying the details of the element it was found in.
+type Edge struct {
+	From       string            `json:"from"`
+	To         string            `json:"to"`
+	Href       string            `json:"href,omitempty"` // the link as written in the page, before resolving
+	AnchorText string            `json:"anchor_text,omitempty"`
+	Title      string            `json:"title,omitempty"`
+	Rel        []string          `json:"rel,omitempty"`
+	Hreflang   string            `json:"hreflang,omitempty"`
+	Region     string            `json:"region,omitempty"`  // the landmark containing the link, e.g., "nav"
+	Element    string            `json:"element,omitempty"` // the tag the link was found in, e.g., "a"
+	Attrs      map[string]string `json:"attrs,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// node holds the edges of a single page.
+type node struct {
+	out []Edge
+	in  []Edge
+}
+
+/*****************************************************************************************************************/
+
+// LinkGraph is a directed graph of pages keyed by canonical URL, with an edge for every link between them.
+// Unlike the crawl tree, it keeps every link to an already-visited page, so it captures inbound link counts
+// and cycles. It is safe for concurrent use.
+type LinkGraph struct {
+	mu    sync.RWMutex
+	root  string
+	nodes map[string]*node
+	order []string // node URLs in insertion order, for deterministic iteration
+}
+
+/*****************************************************************************************************************/
+
+// New creates a LinkGraph containing only the root page.
+func New(root string) *LinkGraph {
+	g := &LinkGraph{
+		root:  root,
+		nodes: make(map[string]*node),
+	}
+
+	g.AddNode(root)
+
+	return g
+}
+
+/*****************************************************************************************************************/
+
+// Root returns the URL of the root page.
+func (g *LinkGraph) Root() string {
+	return g.root
+}
+
+/*****************************************************************************************************************/
+
+// AddNode adds a page to the graph, if it is not already present.
+func (g *LinkGraph) AddNode(url string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addNode(url)
+}
+
+/*****************************************************************************************************************/
+
+// AddEdge adds a link between two pages, adding either page if it is not already present.
+func (g *LinkGraph) AddEdge(edge Edge) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	from := g.addNode(edge.From)
+	to := g.addNode(edge.To)
+
+	from.out = append(from.out, edge)
+	to.in = append(to.in, edge)
+}
+
+/*****************************************************************************************************************/
+
+// Has checks if a page is in the graph.
+func (g *LinkGraph) Has(url string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	_, ok := g.nodes[url]
+
+	return ok
+}
+
+/*****************************************************************************************************************/
+
+// Nodes returns the URLs of every page in the graph, in the order they were added.
+func (g *LinkGraph) Nodes() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return append([]string(nil), g.order...)
+}
+
+/*****************************************************************************************************************/
+
+// Outlinks returns the links from a page, in the order they were found.
+func (g *LinkGraph) Outlinks(url string) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if n, ok := g.nodes[url]; ok {
+		return append([]Edge(nil), n.out...)
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// Inlinks returns the links to a page, in the order they were found.
+func (g *LinkGraph) Inlinks(url string) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if n, ok := g.nodes[url]; ok {
+		return append([]Edge(nil), n.in...)
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// ShortestPath returns the pages on a shortest path of links from the root to a page, including both ends, or
+// nil when the page cannot be reached from the root.
+func (g *LinkGraph) ShortestPath(url string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.nodes[url]; !ok {
+		return nil
+	}
+
+	// Breadth-first search from the root, remembering how each page was first reached:
+	previous := map[string]string{g.root: ""}
+
+	queue := []string{g.root}
+
+	for len(queue) > 0 && previous[url] == "" && url != g.root {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range g.nodes[current].out {
+			if _, seen := previous[edge.To]; !seen {
+				previous[edge.To] = current
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	if _, reached := previous[url]; !reached {
+		return nil
+	}
+
+	var path []string
+
+	for current := url; current != ""; current = previous[current] {
+		path = append([]string{current}, path...)
+	}
+
+	return path
+}
+
+/*****************************************************************************************************************/
+
+// StronglyConnectedComponents returns the groups of pages which can all reach each other through links, using
+// Tarjan's algorithm. Components with more than one page (or a page linking to itself) are link cycles.
+func (g *LinkGraph) StronglyConnectedComponents() [][]string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	t := &tarjan{
+		graph:   g,
+		index:   make(map[string]int),
+		lowlink: make(map[string]int),
+		onStack: make(map[string]bool),
+	}
+
+	for _, url := range g.order {
+		if _, visited := t.index[url]; !visited {
+			t.connect(url)
+		}
+	}
+
+	return t.components
+}
+
+/*****************************************************************************************************************/
+
+// MarshalJSON serialises the graph as its root, pages and edges.
+func (g *LinkGraph) MarshalJSON() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	edges := []Edge{}
+
+	for _, url := range g.order {
+		edges = append(edges, g.nodes[url].out...)
+	}
+
+	return json.Marshal(struct {
+		Root  string   `json:"root"`
+		Nodes []string `json:"nodes"`
+		Edges []Edge   `json:"edges"`
+	}{
+		Root:  g.root,
+		Nodes: g.order,
+		Edges: edges,
+	})
+}
+
+/*****************************************************************************************************************/
+
+// addNode returns the node of a page, adding it first if needed. Callers hold g.mu.
+func (g *LinkGraph) addNode(url string) *node {
+	if n, ok := g.nodes[url]; ok {
+		return n
+	}
+
+	n := &node{}
+
+	g.nodes[url] = n
+	g.order = append(g.order, url)
+
+	return n
+}
+
+/*****************************************************************************************************************/
+
+// tarjan holds the state of a run of Tarjan's strongly connected components algorithm.
+type tarjan struct {
+	graph      *LinkGraph
+	next       int
+	index      map[string]int
+	lowlink    map[string]int
+	stack      []string
+	onStack    map[string]bool
+	components [][]string
+}
+
+/*****************************************************************************************************************/
+
+// connect visits a page and everything reachable from it, emitting each component once its root is found.
+func (t *tarjan) connect(url string) {
+	t.index[url] = t.next
+	t.lowlink[url] = t.next
+	t.next++
+
+	t.stack = append(t.stack, url)
+	t.onStack[url] = true
+
+	for _, edge := range t.graph.nodes[url].out {
+		if _, visited := t.index[edge.To]; !visited {
+			t.connect(edge.To)
+			t.lowlink[url] = min(t.lowlink[url], t.lowlink[edge.To])
+		} else if t.onStack[edge.To] {
+			t.lowlink[url] = min(t.lowlink[url], t.index[edge.To])
+		}
+	}
+
+	if t.lowlink[url] != t.index[url] {
+		return
+	}
+
+	var component []string
+
+	for {
+		top := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[top] = false
+
+		component = append(component, top)
+
+		if top == url {
+			break
+		}
+	}
+
+	t.components = append(t.components, component)
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,145 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package graph
+
+/*****************************************************************************************************************/
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+// newTestGraph builds the graph:
+//
+//	root -> a -> b -> a (cycle)
+//	root -> c -> c (self link)
+//	d -> root (not reachable from the root)
+func newTestGraph() *LinkGraph {
+	g := New("root")
+
+	g.AddEdge(Edge{From: "root", To: "a", AnchorText: "A"})
+	g.AddEdge(Edge{From: "a", To: "b", AnchorText: "B"})
+	g.AddEdge(Edge{From: "b", To: "a", AnchorText: "Back to A", Attrs: map[string]string{"rel": "prev"}})
+	g.AddEdge(Edge{From: "root", To: "c", AnchorText: "C"})
+	g.AddEdge(Edge{From: "c", To: "c", AnchorText: "Self"})
+	g.AddEdge(Edge{From: "d", To: "root", AnchorText: "Home"})
+
+	return g
+}
+
+/*****************************************************************************************************************/
+
+func TestNodes(t *testing.T) {
+	g := newTestGraph()
+
+	expected := []string{"root", "a", "b", "c", "d"}
+
+	if got := g.Nodes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected nodes %v, got %v", expected, got)
+	}
+
+	if !g.Has("b") || g.Has("missing") {
+		t.Error("Expected Has to report graph membership")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestInlinksAndOutlinks(t *testing.T) {
+	g := newTestGraph()
+
+	inlinks := g.Inlinks("a")
+
+	if len(inlinks) != 2 {
+		t.Fatalf("Expected 2 inlinks to a, got %d", len(inlinks))
+	}
+
+	if inlinks[0].From != "root" || inlinks[1].From != "b" || inlinks[1].Attrs["rel"] != "prev" {
+		t.Errorf("Unexpected inlinks: %+v", inlinks)
+	}
+
+	outlinks := g.Outlinks("root")
+
+	if len(outlinks) != 2 || outlinks[0].To != "a" || outlinks[1].To != "c" {
+		t.Errorf("Unexpected outlinks: %+v", outlinks)
+	}
+
+	if g.Outlinks("missing") != nil {
+		t.Error("Expected no outlinks for a missing page")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestShortestPath(t *testing.T) {
+	g := newTestGraph()
+
+	tests := []struct {
+		url      string
+		expected []string
+	}{
+		{"root", []string{"root"}},
+		{"a", []string{"root", "a"}},
+		{"b", []string{"root", "a", "b"}},
+		{"d", nil},
+		{"missing", nil},
+	}
+
+	for _, tc := range tests {
+		if got := g.ShortestPath(tc.url); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("ShortestPath(%q): expected %v, got %v", tc.url, tc.expected, got)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	g := newTestGraph()
+
+	var components []string
+
+	for _, component := range g.StronglyConnectedComponents() {
+		sort.Strings(component)
+		components = append(components, strings.Join(component, ","))
+	}
+
+	sort.Strings(components)
+
+	expected := []string{"a,b", "c", "d", "root"}
+
+	if !reflect.DeepEqual(components, expected) {
+		t.Errorf("Expected components %v, got %v", expected, components)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestMarshalJSON(t *testing.T) {
+	g := New("root")
+
+	g.AddEdge(Edge{From: "root", To: "a", Href: "/a", AnchorText: "A", Rel: []string{"next"}, Region: "nav"})
+
+	data, err := json.Marshal(g)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `{"root":"root","nodes":["root","a"],"edges":[{"from":"root","to":"a","href":"/a","anchor_text":"A","rel":["next"],"region":"nav"}]}`
+
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,308 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package limit
+
+/*****************************************************************************************************************/
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+const (
+	// latencySmoothing is the weight of a new sample in the smoothed latency of a host.
+	latencySmoothing = 0.2
+
+	// baselineDrift is how quickly the baseline latency follows a host which has become slower for good, so its
+	// limit is not pinned to the minimum forever.
+	baselineDrift = 0.01
+
+	// errorSmoothing is the weight of a new outcome in the error rate of a host.
+	errorSmoothing = 0.1
+)
+
+/*****************************************************************************************************************/
+
+// AdaptivePolicy configures the AIMD (additive increase, multiplicative decrease) controller which adjusts the
+// concurrency limit of a host to its health, replacing the fixed MaxConcurrent of its policy. The limit grows by
+// one for every window of healthy responses, and is cut by DecreaseFactor as soon as the host is throttling us,
+// failing, or slowing down.
+type AdaptivePolicy struct {
+	// MinConcurrent is the limit the controller starts from, and never goes below.
+	MinConcurrent int `json:"min_concurrent"`
+	// MaxConcurrent is the limit the controller never goes above.
+	MaxConcurrent int `json:"max_concurrent"`
+	// DecreaseFactor multiplies the limit when the host is congested, e.g., 0.5 to halve it.
+	DecreaseFactor float64 `json:"decrease_factor"`
+	// LatencyTolerance is how many times slower than its baseline a host may respond before it is considered
+	// congested, e.g., 2 for twice as slow.
+	LatencyTolerance float64 `json:"latency_tolerance"`
+	// MaxErrorRate is the smoothed share of failed requests, e.g., 5xx responses, above which the host is
+	// considered congested.
+	MaxErrorRate float64 `json:"max_error_rate"`
+}
+
+/*****************************************************************************************************************/
+
+// DefaultAdaptivePolicy starts every host at a single request in flight, growing up to 16 while it stays healthy,
+// and halving on congestion.
+var DefaultAdaptivePolicy = AdaptivePolicy{
+	MinConcurrent:    1,
+	MaxConcurrent:    16,
+	DecreaseFactor:   0.5,
+	LatencyTolerance: 2,
+	MaxErrorRate:     0.1,
+}
+
+/*****************************************************************************************************************/
+
+// Validate reports whether the adaptive policy values are usable.
+func (p AdaptivePolicy) Validate() error {
+	if p.MinConcurrent < 1 {
+		return errors.New("adaptive min concurrent must be at least 1")
+	}
+
+	if p.MaxConcurrent < p.MinConcurrent {
+		return errors.New("adaptive max concurrent must not be less than min concurrent")
+	}
+
+	if p.DecreaseFactor <= 0 || p.DecreaseFactor >= 1 {
+		return errors.New("adaptive decrease factor must be between 0 and 1")
+	}
+
+	if p.LatencyTolerance <= 1 {
+		return errors.New("adaptive latency tolerance must be greater than 1")
+	}
+
+	if p.MaxErrorRate <= 0 || p.MaxErrorRate > 1 {
+		return errors.New("adaptive max error rate must be between 0 and 1")
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// Outcome describes a completed request to a host, as fed back to its controller with HostLimiter.Observe.
+type Outcome struct {
+	// Latency is the time until the response headers were received.
+	Latency time.Duration
+	// Throttled is set when the host asked us to slow down, i.e., 429 Too Many Requests or 503 Service Unavailable.
+	Throttled bool
+	// Failed is set when no response was received, or the host failed with any other 5xx status code.
+	Failed bool
+}
+
+/*****************************************************************************************************************/
+
+// HostStats is a snapshot of the limits and health of a host.
+type HostStats struct {
+	Host              string  `json:"host"`
+	Adaptive          bool    `json:"adaptive"`                      // the limit is adjusted by an AIMD controller
+	Limit             int     `json:"limit"`                         // maximum number of requests in flight, 0 for no limit
+	InFlight          int     `json:"in_flight"`                     // requests currently in flight
+	Waiting           int     `json:"waiting"`                       // requests waiting for a slot
+	Requests          int     `json:"requests"`                      // completed requests
+	Throttled         int     `json:"throttled"`                     // 429 and 503 responses
+	Failures          int     `json:"failures"`                      // transport errors and other 5xx responses
+	Decreases         int     `json:"decreases"`                     // times the adaptive limit was cut
+	LatencyMs         int64   `json:"latency_ms"`                    // smoothed time until the response headers
+	BaselineLatencyMs int64   `json:"baseline_latency_ms,omitempty"` // the latency of the host when healthy
+	ErrorRate         float64 `json:"error_rate"`                    // smoothed share of throttled and failed requests
+}
+
+/*****************************************************************************************************************/
+
+// controller tracks the health of a host and, when an adaptive policy is set, adjusts its concurrency limit.
+type controller struct {
+	policy       *AdaptivePolicy // nil when the limit is fixed
+	window       int             // the concurrency limit
+	healthy      int             // healthy responses since the window last changed
+	latency      time.Duration
+	baseline     time.Duration
+	errorRate    float64
+	lastDecrease time.Time
+	requests     int
+	throttled    int
+	failures     int
+	decreases    int
+}
+
+/*****************************************************************************************************************/
+
+// newController creates the controller of a host, starting from the minimum limit of an adaptive policy.
+func newController(policy *AdaptivePolicy) *controller {
+	c := &controller{policy: policy}
+
+	if policy != nil {
+		c.window = policy.MinConcurrent
+	}
+
+	return c
+}
+
+/*****************************************************************************************************************/
+
+// observe updates the health of the host with the outcome of a request, adjusting the window of an adaptive
+// controller.
+func (c *controller) observe(outcome Outcome, now time.Time) {
+	c.requests++
+
+	failed := 0.0
+
+	switch {
+	case outcome.Throttled:
+		c.throttled++
+		failed = 1
+	case outcome.Failed:
+		c.failures++
+		failed = 1
+	default:
+		c.sample(outcome.Latency)
+	}
+
+	c.errorRate += errorSmoothing * (failed - c.errorRate)
+
+	if c.policy == nil {
+		return
+	}
+
+	if outcome.Throttled || c.congested() {
+		c.decrease(now)
+		return
+	}
+
+	// One more request in flight is allowed for every window of healthy responses:
+	if c.healthy++; c.healthy >= c.window && c.window < c.policy.MaxConcurrent {
+		c.window++
+		c.healthy = 0
+	}
+}
+
+/*****************************************************************************************************************/
+
+// sample adds the latency of a successful request to the smoothed latency and the baseline.
+func (c *controller) sample(latency time.Duration) {
+	if c.latency == 0 {
+		c.latency = latency
+	} else {
+		c.latency += time.Duration(latencySmoothing * float64(latency-c.latency))
+	}
+
+	if c.baseline == 0 || c.latency < c.baseline {
+		c.baseline = c.latency
+	} else {
+		c.baseline += time.Duration(baselineDrift * float64(c.latency-c.baseline))
+	}
+}
+
+/*****************************************************************************************************************/
+
+// congested checks if the host is failing too often, or responding too slowly compared to its baseline.
+func (c *controller) congested() bool {
+	if c.errorRate > c.policy.MaxErrorRate {
+		return true
+	}
+
+	return c.baseline > 0 && float64(c.latency) > c.policy.LatencyTolerance*float64(c.baseline)
+}
+
+/*****************************************************************************************************************/
+
+// decrease cuts the window by the decrease factor, at most once per round trip, as the responses still in flight
+// were sent at the old limit and say nothing about the new one.
+func (c *controller) decrease(now time.Time) {
+	if !c.lastDecrease.IsZero() && now.Sub(c.lastDecrease) < c.latency {
+		return
+	}
+
+	window := max(int(float64(c.window)*c.policy.DecreaseFactor), c.policy.MinConcurrent)
+
+	if window < c.window {
+		c.decreases++
+	}
+
+	c.window = window
+	c.healthy = 0
+	c.lastDecrease = now
+}
+
+/*****************************************************************************************************************/
+
+// limit returns the current concurrency limit of an adaptive controller.
+func (c *controller) limit() int {
+	return c.window
+}
+
+/*****************************************************************************************************************/
+
+// Observe feeds the outcome of a completed request back to the controller of its host, raising or cutting the
+// host's concurrency limit when its policy is adaptive. Requests which were cancelled should not be observed.
+func (l *HostLimiter) Observe(host string, outcome Outcome) {
+	state := l.state(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state.health.observe(outcome, time.Now())
+
+	if state.health.policy == nil {
+		return
+	}
+
+	state.limit = state.health.limit()
+
+	// A raised limit frees slots for waiting requests straight away, while a cut one takes effect as requests in
+	// flight are released:
+	for len(state.waiters) > 0 && state.inflight < state.limit {
+		wait := state.waiters[0]
+		state.waiters = state.waiters[1:]
+		state.inflight++
+		close(wait)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// Stats returns a snapshot of the limits and health of every host requested so far, sorted by host.
+func (l *HostLimiter) Stats() []HostStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make([]HostStats, 0, len(l.hosts))
+
+	for host, state := range l.hosts {
+		health := state.health
+
+		stats = append(stats, HostStats{
+			Host:              host,
+			Adaptive:          health.policy != nil,
+			Limit:             state.limit,
+			InFlight:          state.inflight,
+			Waiting:           len(state.waiters),
+			Requests:          health.requests,
+			Throttled:         health.throttled,
+			Failures:          health.failures,
+			Decreases:         health.decreases,
+			LatencyMs:         health.latency.Milliseconds(),
+			BaselineLatencyMs: health.baseline.Milliseconds(),
+			ErrorRate:         health.errorRate,
+		})
+	}
+
+	slices.SortFunc(stats, func(a, b HostStats) int {
+		return strings.Compare(a.Host, b.Host)
+	})
+
+	return stats
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,266 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package limit
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+func TestAdaptivePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(p *AdaptivePolicy)
+		invalid bool
+	}{
+		{
+			name:   "Default policy",
+			modify: func(p *AdaptivePolicy) {},
+		},
+		{
+			name:    "Zero min concurrent",
+			modify:  func(p *AdaptivePolicy) { p.MinConcurrent = 0 },
+			invalid: true,
+		},
+		{
+			name:    "Max below min concurrent",
+			modify:  func(p *AdaptivePolicy) { p.MaxConcurrent = 0 },
+			invalid: true,
+		},
+		{
+			name:    "Decrease factor of one",
+			modify:  func(p *AdaptivePolicy) { p.DecreaseFactor = 1 },
+			invalid: true,
+		},
+		{
+			name:    "Latency tolerance of one",
+			modify:  func(p *AdaptivePolicy) { p.LatencyTolerance = 1 },
+			invalid: true,
+		},
+		{
+			name:    "Zero max error rate",
+			modify:  func(p *AdaptivePolicy) { p.MaxErrorRate = 0 },
+			invalid: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := DefaultAdaptivePolicy
+
+			tc.modify(&policy)
+
+			if err := policy.Validate(); (err != nil) != tc.invalid {
+				t.Errorf("Test %s failed: expected invalid %v, got %v", tc.name, tc.invalid, err)
+			}
+
+			// Invalid adaptive policies make the host policy invalid too:
+			if err := (Policy{Adaptive: &policy}).Validate(); (err != nil) != tc.invalid {
+				t.Errorf("Test %s failed: expected invalid host policy %v, got %v", tc.name, tc.invalid, err)
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestControllerAdditiveIncrease(t *testing.T) {
+	policy := DefaultAdaptivePolicy
+	policy.MaxConcurrent = 4
+
+	c := newController(&policy)
+
+	now := time.Now()
+
+	healthy := Outcome{Latency: 100 * time.Millisecond}
+
+	// One request in flight grows to two after a single healthy response, and two to three after two more:
+	c.observe(healthy, now)
+
+	if c.limit() != 2 {
+		t.Fatalf("Expected a limit of 2, got %d", c.limit())
+	}
+
+	c.observe(healthy, now)
+	c.observe(healthy, now)
+
+	if c.limit() != 3 {
+		t.Fatalf("Expected a limit of 3, got %d", c.limit())
+	}
+
+	for range 100 {
+		c.observe(healthy, now)
+	}
+
+	if c.limit() != 4 {
+		t.Errorf("Expected the limit to stop at the maximum of 4, got %d", c.limit())
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestControllerMultiplicativeDecrease(t *testing.T) {
+	policy := DefaultAdaptivePolicy
+
+	c := newController(&policy)
+
+	c.window = 16
+
+	now := time.Now()
+
+	c.observe(Outcome{Latency: 100 * time.Millisecond}, now)
+
+	// Being throttled halves the limit:
+	c.observe(Outcome{Throttled: true}, now)
+
+	if c.limit() != 8 {
+		t.Fatalf("Expected a limit of 8, got %d", c.limit())
+	}
+
+	// Responses to requests sent before the cut do not cut it again within the same round trip:
+	c.observe(Outcome{Throttled: true}, now.Add(50*time.Millisecond))
+
+	if c.limit() != 8 {
+		t.Fatalf("Expected the limit to remain 8, got %d", c.limit())
+	}
+
+	c.observe(Outcome{Throttled: true}, now.Add(150*time.Millisecond))
+
+	if c.limit() != 4 {
+		t.Fatalf("Expected a limit of 4, got %d", c.limit())
+	}
+
+	// The limit never goes below the minimum:
+	for i := range 10 {
+		c.observe(Outcome{Throttled: true}, now.Add(time.Duration(i+1)*time.Second))
+	}
+
+	if c.limit() != policy.MinConcurrent {
+		t.Errorf("Expected the limit to stop at the minimum of %d, got %d", policy.MinConcurrent, c.limit())
+	}
+
+	if c.throttled != 13 || c.decreases != 4 {
+		t.Errorf("Expected 13 throttled responses and 4 decreases, got %d and %d", c.throttled, c.decreases)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestControllerCongestion(t *testing.T) {
+	policy := DefaultAdaptivePolicy
+
+	now := time.Now()
+
+	// Rising response times cut the limit once the smoothed latency exceeds the tolerance:
+	slow := newController(&policy)
+	slow.window = 8
+
+	slow.observe(Outcome{Latency: 100 * time.Millisecond}, now)
+
+	for i := range 10 {
+		slow.observe(Outcome{Latency: time.Second}, now.Add(time.Duration(i)*10*time.Second))
+	}
+
+	if slow.decreases == 0 || slow.limit() >= 8 {
+		t.Errorf("Expected rising latency to cut the limit, got %d after %d decreases", slow.limit(), slow.decreases)
+	}
+
+	// A single failure is tolerated, but repeated failures raise the error rate above the maximum:
+	failing := newController(&policy)
+	failing.window = 8
+
+	failing.observe(Outcome{Latency: 100 * time.Millisecond}, now)
+	failing.observe(Outcome{Failed: true}, now)
+
+	if failing.decreases != 0 {
+		t.Errorf("Expected a single failure to be tolerated, got %d decreases", failing.decreases)
+	}
+
+	failing.observe(Outcome{Failed: true}, now.Add(time.Second))
+
+	if failing.decreases != 1 || failing.limit() != 4 {
+		t.Errorf("Expected repeated failures to halve the limit, got %d after %d decreases", failing.limit(), failing.decreases)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestHostLimiterObserve(t *testing.T) {
+	policy := DefaultAdaptivePolicy
+
+	l := NewHostLimiter(Policy{Adaptive: &policy})
+
+	release, err := l.Acquire(context.Background(), "koroutine.tech")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	acquired := make(chan func())
+
+	go func() {
+		next, err := l.Acquire(context.Background(), "koroutine.tech")
+
+		if err == nil {
+			acquired <- next
+		}
+	}()
+
+	// Wait for the second request to queue behind the first, as the limit starts at one:
+	for l.Stats()[0].Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A healthy response raises the limit, handing a slot to the waiting request before the first is released:
+	l.Observe("koroutine.tech", Outcome{Latency: 10 * time.Millisecond})
+
+	select {
+	case next := <-acquired:
+		next()
+	case <-time.After(time.Second):
+		t.Fatal("Expected the raised limit to let the waiting request through")
+	}
+
+	release()
+
+	stats := l.Stats()
+
+	if len(stats) != 1 {
+		t.Fatalf("Expected stats for 1 host, got %d", len(stats))
+	}
+
+	if !stats[0].Adaptive || stats[0].Limit != 2 || stats[0].InFlight != 0 || stats[0].Requests != 1 || stats[0].LatencyMs != 10 {
+		t.Errorf("Unexpected stats: %+v", stats[0])
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestHostLimiterObserveFixedLimit(t *testing.T) {
+	l := NewHostLimiter(Policy{MaxConcurrent: 2})
+
+	l.Observe("koroutine.tech", Outcome{Throttled: true})
+	l.Observe("example.com", Outcome{Latency: 20 * time.Millisecond})
+
+	stats := l.Stats()
+
+	if len(stats) != 2 || stats[0].Host != "example.com" || stats[1].Host != "koroutine.tech" {
+		t.Fatalf("Expected stats sorted by host, got %+v", stats)
+	}
+
+	// Health is tracked for every host, but only adaptive policies change the limit:
+	if stats[1].Adaptive || stats[1].Limit != 2 || stats[1].Throttled != 1 || stats[1].Decreases != 0 {
+		t.Errorf("Unexpected stats: %+v", stats[1])
+	}
+}
+
+/*****************************************************************************************************************/
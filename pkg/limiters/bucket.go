@@ -0,0 +1,147 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package limit
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+// Bucket is a token bucket rate limiter: tokens are added at a fixed rate per second up to the burst size,
+// and every request takes one token, waiting for it to become available when the bucket is empty.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // maximum number of tokens held
+	tokens float64 // may go negative, representing reservations of future tokens
+	last   time.Time
+	now    func() time.Time
+}
+
+/*****************************************************************************************************************/
+
+// NewBucket creates a full token bucket allowing rate requests per second, with bursts of up to burst requests.
+func NewBucket(rate float64, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+/*****************************************************************************************************************/
+
+// Wait blocks until a token is available or the context is done, in which case the token is given back.
+func (b *Bucket) Wait(ctx context.Context) error {
+	delay := b.reserve()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+/*****************************************************************************************************************/
+
+// SetRate changes the number of tokens added per second and the burst size, e.g., to honour a Crawl-delay. The
+// tokens accumulated at the previous rate are kept, up to the new burst size, as are any reservations.
+func (b *Bucket) SetRate(rate float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+
+	b.rate = rate
+	b.burst = float64(burst)
+	b.tokens = min(b.tokens, b.burst)
+}
+
+/*****************************************************************************************************************/
+
+// Rate returns the number of tokens added per second.
+func (b *Bucket) Rate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.rate
+}
+
+/*****************************************************************************************************************/
+
+// reserve takes a token, returning how long the caller has to wait before it may be used.
+func (b *Bucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A non-positive rate means the bucket does not limit at all:
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.advance()
+
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+/*****************************************************************************************************************/
+
+// cancel gives back a reserved token that was never used.
+func (b *Bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+}
+
+/*****************************************************************************************************************/
+
+// advance adds the tokens accumulated since the last update, capped at the burst size.
+func (b *Bucket) advance() {
+	now := b.now()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	b.last = now
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,119 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package limit
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+func TestBucketBurst(t *testing.T) {
+	b := NewBucket(1, 3)
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected burst to be immediate, took %v", elapsed)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestBucketReserveDelay(t *testing.T) {
+	now := time.Now()
+
+	b := NewBucket(10, 1)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	if delay := b.reserve(); delay != 0 {
+		t.Errorf("Expected first token to be immediate, got %v", delay)
+	}
+
+	if delay := b.reserve(); delay != 100*time.Millisecond {
+		t.Errorf("Expected second token after 100ms, got %v", delay)
+	}
+
+	if delay := b.reserve(); delay != 200*time.Millisecond {
+		t.Errorf("Expected third token after 200ms, got %v", delay)
+	}
+
+	// Time passing refills the bucket:
+	now = now.Add(time.Second)
+
+	if delay := b.reserve(); delay != 0 {
+		t.Errorf("Expected refilled token to be immediate, got %v", delay)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestBucketWaitCancelled(t *testing.T) {
+	b := NewBucket(0.1, 1)
+
+	_ = b.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestBucketUnlimited(t *testing.T) {
+	b := NewBucket(0, 1)
+
+	for i := 0; i < 100; i++ {
+		if delay := b.reserve(); delay != 0 {
+			t.Fatalf("Expected no delay for an unlimited bucket, got %v", delay)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestBucketSetRate(t *testing.T) {
+	now := time.Now()
+
+	b := NewBucket(10, 5)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	// The full bucket is capped at the new burst size:
+	b.SetRate(2, 1)
+
+	if delay := b.reserve(); delay != 0 {
+		t.Errorf("Expected first token to be immediate, got %v", delay)
+	}
+
+	// The token just taken still counts at the new rate, rather than the bucket being refilled:
+	if delay := b.reserve(); delay != 500*time.Millisecond {
+		t.Errorf("Expected second token after 500ms, got %v", delay)
+	}
+
+	b.SetRate(1, 1)
+
+	if delay := b.reserve(); delay != 2*time.Second {
+		t.Errorf("Expected third token after 2s, got %v", delay)
+	}
+}
+
+/*****************************************************************************************************************/
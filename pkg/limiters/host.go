@@ -0,0 +1,315 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package limit
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+// Policy describes how politely requests to a single host are made.
+type Policy struct {
+	// RequestsPerSecond is the sustained request rate allowed per host (0 for no limit).
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// Burst is the number of requests which may be made back-to-back before the rate applies.
+	Burst int `json:"burst"`
+	// MaxConcurrent is the maximum number of requests in flight per host (0 for no limit).
+	MaxConcurrent int `json:"max_concurrent"`
+	// Adaptive replaces MaxConcurrent with a limit adjusted to the health of each host, see AdaptivePolicy.
+	Adaptive *AdaptivePolicy `json:"adaptive,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// DefaultPolicy is a polite default: at most two requests in flight, and two requests per second, per host.
+var DefaultPolicy = Policy{
+	RequestsPerSecond: 2,
+	Burst:             5,
+	MaxConcurrent:     2,
+}
+
+/*****************************************************************************************************************/
+
+// Validate reports whether the policy values are usable.
+func (p Policy) Validate() error {
+	if p.RequestsPerSecond < 0 {
+		return errors.New("requests per second must not be negative")
+	}
+
+	if p.Burst < 0 {
+		return errors.New("burst must not be negative")
+	}
+
+	if p.MaxConcurrent < 0 {
+		return errors.New("max concurrent must not be negative")
+	}
+
+	if p.Adaptive != nil {
+		return p.Adaptive.Validate()
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// Rule overrides the default policy for every host matching Pattern, a glob such as "*.example.com".
+type Rule struct {
+	Pattern string `json:"pattern"`
+	Policy  Policy `json:"policy"`
+}
+
+/*****************************************************************************************************************/
+
+// Validate reports whether the rule pattern and policy are usable.
+func (r Rule) Validate() error {
+	if r.Pattern == "" {
+		return errors.New("host pattern must not be empty")
+	}
+
+	if _, err := path.Match(r.Pattern, ""); err != nil {
+		return fmt.Errorf("host pattern %q: %w", r.Pattern, err)
+	}
+
+	return r.Policy.Validate()
+}
+
+/*****************************************************************************************************************/
+
+// Matches checks if a host matches the rule pattern, ignoring case and any port.
+func (r Rule) Matches(host string) bool {
+	return MatchHost(r.Pattern, host)
+}
+
+/*****************************************************************************************************************/
+
+// MatchHost checks if a host matches a glob pattern such as "*.example.com", ignoring case and any port.
+func MatchHost(pattern string, host string) bool {
+	matched, _ := path.Match(strings.ToLower(pattern), normaliseHost(host))
+	return matched
+}
+
+/*****************************************************************************************************************/
+
+// hostState holds the rate limiter, concurrency slots and health of a single host.
+type hostState struct {
+	bucket   *Bucket
+	limit    int // maximum number of requests in flight, 0 for no limit
+	inflight int
+	waiters  []chan struct{}
+	health   *controller
+}
+
+/*****************************************************************************************************************/
+
+// HostLimiter enforces a request rate and concurrency limit per host, using the first matching rule or the
+// default policy for each host.
+type HostLimiter struct {
+	mu     sync.Mutex
+	policy Policy
+	rules  []Rule
+	hosts  map[string]*hostState
+}
+
+/*****************************************************************************************************************/
+
+// NewHostLimiter creates a HostLimiter with a default policy and optional per-host overrides, which are
+// evaluated in order.
+func NewHostLimiter(policy Policy, rules ...Rule) *HostLimiter {
+	return &HostLimiter{
+		policy: policy,
+		rules:  rules,
+		hosts:  make(map[string]*hostState),
+	}
+}
+
+/*****************************************************************************************************************/
+
+// PolicyFor returns the policy which applies to a host.
+func (l *HostLimiter) PolicyFor(host string) Policy {
+	for _, rule := range l.rules {
+		if rule.Matches(host) {
+			return rule.Policy
+		}
+	}
+
+	return l.policy
+}
+
+/*****************************************************************************************************************/
+
+// Acquire blocks until a request to the host is allowed by both its concurrency and rate limits. The returned
+// release function must be called once the request has completed.
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	state := l.state(host)
+
+	if err := l.acquireSlot(ctx, state); err != nil {
+		return nil, err
+	}
+
+	release := func() { l.releaseSlot(state) }
+
+	l.mu.Lock()
+	bucket := state.bucket
+	l.mu.Unlock()
+
+	if bucket != nil {
+		if err := bucket.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+/*****************************************************************************************************************/
+
+// SetMinInterval slows a host down to at most one request per interval, e.g., to honour a robots.txt
+// Crawl-delay. Hosts already limited to the same or a slower rate are left unchanged, while the bucket of faster
+// hosts is slowed down in place, so requests just made still count against the interval.
+func (l *HostLimiter) SetMinInterval(host string, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	state := l.state(host)
+
+	rate := float64(time.Second) / float64(interval)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state.bucket != nil && state.bucket.Rate() <= rate {
+		return
+	}
+
+	if state.bucket == nil {
+		state.bucket = NewBucket(rate, 1)
+		return
+	}
+
+	state.bucket.SetRate(rate, 1)
+}
+
+/*****************************************************************************************************************/
+
+// state returns the state of a host, creating it from the applicable policy on first use.
+func (l *HostLimiter) state(host string) *hostState {
+	host = normaliseHost(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state, ok := l.hosts[host]; ok {
+		return state
+	}
+
+	policy := l.PolicyFor(host)
+
+	state := &hostState{limit: policy.MaxConcurrent, health: newController(policy.Adaptive)}
+
+	if policy.Adaptive != nil {
+		state.limit = state.health.limit()
+	}
+
+	if policy.RequestsPerSecond > 0 {
+		state.bucket = NewBucket(policy.RequestsPerSecond, policy.Burst)
+	}
+
+	l.hosts[host] = state
+
+	return state
+}
+
+/*****************************************************************************************************************/
+
+// acquireSlot waits for one of the host's concurrency slots to become free.
+func (l *HostLimiter) acquireSlot(ctx context.Context, state *hostState) error {
+	l.mu.Lock()
+
+	if state.limit <= 0 || state.inflight < state.limit {
+		state.inflight++
+		l.mu.Unlock()
+		return nil
+	}
+
+	wait := make(chan struct{})
+
+	state.waiters = append(state.waiters, wait)
+
+	l.mu.Unlock()
+
+	select {
+	case <-wait:
+		// The slot has been handed over by releaseSlot:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for i, w := range state.waiters {
+			if w == wait {
+				state.waiters = append(state.waiters[:i], state.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+
+		// The slot was handed over concurrently with the cancellation, so pass it on:
+		l.handOver(state)
+
+		return ctx.Err()
+	}
+}
+
+/*****************************************************************************************************************/
+
+// releaseSlot frees a concurrency slot of the host, handing it over to the next waiter if there is one.
+func (l *HostLimiter) releaseSlot(state *hostState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.handOver(state)
+}
+
+/*****************************************************************************************************************/
+
+// handOver passes a held slot to the first waiter, or frees it when nobody is waiting. Callers hold l.mu.
+func (l *HostLimiter) handOver(state *hostState) {
+	if len(state.waiters) > 0 && (state.limit <= 0 || state.inflight <= state.limit) {
+		wait := state.waiters[0]
+		state.waiters = state.waiters[1:]
+		close(wait)
+		return
+	}
+
+	state.inflight--
+}
+
+/*****************************************************************************************************************/
+
+// normaliseHost lowercases a host and strips any port, so limits apply per host name.
+func normaliseHost(host string) string {
+	host = strings.ToLower(host)
+
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+
+	return host
+}
+
+/*****************************************************************************************************************/
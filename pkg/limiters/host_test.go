@@ -0,0 +1,188 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package limit
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		host     string
+		expected bool
+	}{
+		{
+			name:     "Exact host",
+			pattern:  "koroutine.tech",
+			host:     "koroutine.tech",
+			expected: true,
+		},
+		{
+			name:     "Host with port",
+			pattern:  "koroutine.tech",
+			host:     "koroutine.tech:8080",
+			expected: true,
+		},
+		{
+			name:     "Case insensitive",
+			pattern:  "Koroutine.Tech",
+			host:     "KOROUTINE.tech",
+			expected: true,
+		},
+		{
+			name:     "Subdomain wildcard",
+			pattern:  "*.koroutine.tech",
+			host:     "www.koroutine.tech",
+			expected: true,
+		},
+		{
+			name:     "Subdomain wildcard excludes apex",
+			pattern:  "*.koroutine.tech",
+			host:     "koroutine.tech",
+			expected: false,
+		},
+		{
+			name:     "Different host",
+			pattern:  "koroutine.tech",
+			host:     "example.com",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := Rule{Pattern: tc.pattern}
+
+			if got := rule.Matches(tc.host); got != tc.expected {
+				t.Errorf("Test %s failed: expected %v, got %v", tc.name, tc.expected, got)
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestRuleValidate(t *testing.T) {
+	if err := (Rule{Pattern: "[", Policy: DefaultPolicy}).Validate(); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+
+	if err := (Rule{Pattern: "*.koroutine.tech", Policy: Policy{Burst: -1}}).Validate(); err == nil {
+		t.Error("Expected an error for a negative burst")
+	}
+
+	if err := (Rule{Pattern: "*.koroutine.tech", Policy: DefaultPolicy}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestHostLimiterPolicyFor(t *testing.T) {
+	fast := Policy{RequestsPerSecond: 50, Burst: 50, MaxConcurrent: 10}
+
+	l := NewHostLimiter(DefaultPolicy, Rule{Pattern: "*.koroutine.tech", Policy: fast})
+
+	if got := l.PolicyFor("www.koroutine.tech"); got != fast {
+		t.Errorf("Expected override policy, got %+v", got)
+	}
+
+	if got := l.PolicyFor("example.com"); got != DefaultPolicy {
+		t.Errorf("Expected default policy, got %+v", got)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestHostLimiterConcurrency(t *testing.T) {
+	l := NewHostLimiter(Policy{MaxConcurrent: 1})
+
+	release, err := l.Acquire(context.Background(), "koroutine.tech")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Other hosts are unaffected:
+	other, err := l.Acquire(context.Background(), "example.com")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	other()
+
+	// A second request to the same host has to wait for the first to be released:
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := l.Acquire(ctx, "koroutine.tech"); err != context.DeadlineExceeded {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+
+	acquired := make(chan struct{})
+
+	go func() {
+		next, err := l.Acquire(context.Background(), "koroutine.tech")
+
+		if err == nil {
+			next()
+		}
+
+		close(acquired)
+	}()
+
+	release()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the waiting request to acquire the released slot")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestHostLimiterSetMinInterval(t *testing.T) {
+	l := NewHostLimiter(Policy{RequestsPerSecond: 100, Burst: 10})
+
+	l.SetMinInterval("koroutine.tech", 50*time.Millisecond)
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		release, err := l.Acquire(context.Background(), "koroutine.tech")
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		release()
+	}
+
+	// The first request is immediate, the following two wait 50ms each:
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected requests to be spaced by the interval, took %v", elapsed)
+	}
+
+	// A faster interval does not speed the host up again:
+	l.SetMinInterval("koroutine.tech", time.Millisecond)
+
+	if rate := l.state("koroutine.tech").bucket.Rate(); rate != 20 {
+		t.Errorf("Expected the rate to remain 20/s, got %v", rate)
+	}
+}
+
+/*****************************************************************************************************************/
@@ -10,6 +10,7 @@ package parse
 
 import (
 	"io"
+	"slices"
 	"strings"
 
 	validate "github.com/michealroberts/koroutine-web-crawler/pkg/validators"
@@ -18,15 +19,138 @@ import (
 
 /*****************************************************************************************************************/
 
-// Extracts all anchor tags from an HTML document and returns the href URLs.
-func AhrefsFromHTML(body io.ReadCloser, base string) []string {
-	// Placeholder for extracted ahrefs:
-	var ahrefs []string
+// Link is a URL found in an attribute of a document's element.
+type Link struct {
+	// Href is the attribute value as written in the document, e.g., "../about".
+	Href string
+	// URL is the attribute value resolved against the document's base URL.
+	URL string
+	// Text is the visible anchor text of <a> elements, including the text and image alt text of nested elements,
+	// with whitespace collapsed.
+	Text string
+	// Title is the title attribute of the element.
+	Title string
+	// Rel are the lowercased rel values of the element, e.g., ["nofollow"] or ["alternate"].
+	Rel []string
+	// Hreflang is the hreflang attribute of the element, e.g., "en-GB".
+	Hreflang string
+	// Type is the lowercased media type of the type attribute, e.g., "application/rss+xml".
+	Type string
+	// Region is the innermost landmark element containing the link: RegionNav, RegionHeader, RegionFooter or
+	// RegionMain, or empty when it is outside all of them.
+	Region string
+	// Element is the tag name of the element, e.g., "a", "img" or "link".
+	Element string
+	// Attribute is the attribute the URL was found in, e.g., "href", "src" or "srcset".
+	Attribute string
+	// Attrs are all attributes of the element, e.g., rel and title.
+	Attrs map[string]string
+}
+
+/*****************************************************************************************************************/
+
+// Regions reported in Link.Region, named after the landmark elements containing links.
+const (
+	RegionNav    = "nav"
+	RegionHeader = "header"
+	RegionFooter = "footer"
+	RegionMain   = "main"
+)
+
+/*****************************************************************************************************************/
+
+// Document is everything extracted from a single pass over an HTML document.
+type Document struct {
+	// Title is the text of the first <title> element, with surrounding whitespace trimmed.
+	Title string
+	// Base is the URL links are resolved against: the href of the first <base> element, resolved against the
+	// document URL, or the document URL itself.
+	Base string
+	// Links are the links navigating to other pages, i.e., anchors, areas, iframes, meta refreshes and
+	// <link rel=canonical|alternate|next|prev>, with a valid http(s) URL, in document order.
+	Links []Link
+	// Page is the metadata of an HTML document, e.g., its description, OpenGraph tags and outline, or nil for
+	// other documents.
+	Page *PageMeta
+	// Structured is the JSON-LD and microdata markup of an HTML document, or nil when it has none.
+	Structured *StructuredData
+	// Meta are the content attributes of <meta name> elements by lowercased name, in document order, e.g.,
+	// Meta["robots"] is ["noindex, nofollow"].
+	Meta map[string][]string
+	// Feeds are the RSS and Atom feeds advertised with <link rel="alternate" type="application/rss+xml"> and the
+	// like, which are also part of Links.
+	Feeds []Link
+	// Entries are the items of a feed, only set for feed documents.
+	Entries []FeedItem
+	// Resources are the links to resources loaded by the page, i.e., images, scripts, stylesheets, icons, media
+	// and form actions, with a valid http(s) URL, in document order.
+	Resources []Link
+}
+
+/*****************************************************************************************************************/
+
+// navigationRels are the <link> rel values which point at other pages rather than resources of this page.
+var navigationRels = []string{"canonical", "alternate", "next", "prev"}
+
+/*****************************************************************************************************************/
+
+// feedTypes are the media types of the feeds advertised by <link rel="alternate"> elements.
+var feedTypes = []string{"application/rss+xml", "application/atom+xml", "application/rdf+xml"}
+
+/*****************************************************************************************************************/
+
+// resourceRels are the <link> rel values which point at resources loaded by (or for) the page.
+var resourceRels = []string{
+	"stylesheet", "icon", "apple-touch-icon", "mask-icon", "manifest", "preload", "modulepreload", "prefetch",
+}
+
+/*****************************************************************************************************************/
+
+// resourceAttributes are the URL attributes of elements which load resources, in the order they are extracted.
+var resourceAttributes = map[string][]string{
+	"img":    {"src", "srcset"},
+	"script": {"src"},
+	"source": {"src", "srcset"},
+	"video":  {"src", "poster"},
+	"audio":  {"src"},
+	"form":   {"action"},
+}
+
+/*****************************************************************************************************************/
+
+// Extracts all anchor tags from an HTML document, in document order. The document is transcoded to UTF-8 from the
+// charset given by its byte order mark or <meta charset>, or sniffed, as there is no Content-Type header to go on.
+func AhrefsFromHTML(body io.ReadCloser, base string) []Link {
+	var ahrefs []Link
+
+	document, _ := NewUTF8Reader(body, "")
+
+	for _, link := range DocumentFromHTML(document, base).Links {
+		if link.Element == "a" {
+			ahrefs = append(ahrefs, link)
+		}
+	}
+
+	return ahrefs
+}
+
+/*****************************************************************************************************************/
+
+// DocumentFromHTML extracts the title, metadata, navigation links and resource links from an HTML document. The base is
+// the document URL, i.e., the final URL after any redirects, and is overridden by the first <base href> element
+// for every link which follows it. The document is read as UTF-8, so others should be transcoded first, e.g., with
+// NewUTF8Reader.
+func DocumentFromHTML(body io.Reader, base string) *Document {
+	p := &htmlParser{
+		doc:     &Document{Base: base, Page: &PageMeta{}, Structured: &StructuredData{}},
+		base:    base,
+		anchor:  -1,
+		heading: -1,
+	}
 
 	// Create an HTML tokenizer to parse the content:
 	tokenizer := html.NewTokenizer(body)
 
-	// Parse the HTML content and extract ahrefs from anchor tags:
 	for {
 		// Get the next token type:
 		tokenType := tokenizer.Next()
@@ -36,26 +160,333 @@ func AhrefsFromHTML(body io.ReadCloser, base string) []string {
 			break
 		}
 
-		token := tokenizer.Token()
+		p.token(tokenType, tokenizer.Token())
+	}
+
+	p.doc.Title = collapseWhitespace(p.doc.Title)
 
-		if tokenType == html.StartTagToken && token.Data == "a" {
-			for _, a := range token.Attr {
-				if a.Key == "href" {
-					resolvedAhref, err := validate.Ahref(base, a.Val)
+	for i := range p.doc.Links {
+		p.doc.Links[i].Text = collapseWhitespace(p.doc.Links[i].Text)
+	}
 
-					if err != nil {
-						continue
-					}
+	p.finishMeta()
 
-					if strings.HasPrefix(resolvedAhref, "http") {
-						ahrefs = append(ahrefs, resolvedAhref)
-					}
+	p.finishStructured()
+
+	return p.doc
+}
+
+/*****************************************************************************************************************/
+
+// htmlParser holds the state of a single pass over an HTML document.
+type htmlParser struct {
+	doc *Document
+	// base is the document URL, which a relative <base href> is resolved against:
+	base string
+	// Whether we are inside the first <title> element:
+	inTitle, seenTitle bool
+	// Whether a <base href> element has already set the base URL:
+	seenBase bool
+	// The index of the link whose anchor text is being collected, or -1 outside of anchors:
+	anchor int
+	// The landmark elements currently open, innermost last:
+	regions []string
+	// The index of the heading whose text is being collected, or -1 outside of headings:
+	heading int
+	// The open element whose text is not visible, e.g., "script", or empty outside of them:
+	hidden string
+	// The text of the open JSON-LD block, or nil outside of them:
+	jsonLD *strings.Builder
+	// The open elements with microdata, innermost last:
+	microdata []*microdataFrame
+}
+
+/*****************************************************************************************************************/
+
+// token processes the next token of the document.
+func (p *htmlParser) token(tokenType html.TokenType, token html.Token) {
+	switch tokenType {
+	case html.TextToken:
+		if p.inTitle {
+			p.doc.Title += token.Data
+		}
+
+		// Anchor text includes the text of any nested elements:
+		if p.anchor != -1 {
+			p.doc.Links[p.anchor].Text += token.Data
+		}
+
+		p.metaText(token.Data)
+
+		p.structuredText(token.Data)
+	case html.EndTagToken:
+		p.metaEndTag(token)
+
+		p.structuredEndTag(token)
+
+		switch token.Data {
+		case "title":
+			p.inTitle = false
+		case "a":
+			p.anchor = -1
+		case RegionNav, RegionHeader, RegionFooter, RegionMain:
+			// Close the innermost open landmark of the same kind, ignoring stray end tags:
+			for i := len(p.regions) - 1; i >= 0; i-- {
+				if p.regions[i] == token.Data {
+					p.regions = p.regions[:i]
+					break
 				}
 			}
 		}
+	case html.StartTagToken, html.SelfClosingTagToken:
+		p.startTag(tokenType, token)
 	}
+}
 
-	return ahrefs
+/*****************************************************************************************************************/
+
+// startTag extracts the links and metadata of an element from its start tag.
+func (p *htmlParser) startTag(tokenType html.TokenType, token html.Token) {
+	attrs := attributes(token)
+
+	p.metaStartTag(tokenType, token, attrs)
+
+	p.structuredStartTag(tokenType, token, attrs)
+
+	switch token.Data {
+	case RegionNav, RegionHeader, RegionFooter, RegionMain:
+		if tokenType == html.StartTagToken {
+			p.regions = append(p.regions, token.Data)
+		}
+	case "img":
+		// The alt text of an image inside an anchor is part of its anchor text:
+		if p.anchor != -1 && attrs["alt"] != "" {
+			p.doc.Links[p.anchor].Text += " " + attrs["alt"] + " "
+		}
+
+		p.addResources(token, attrs)
+	case "title":
+		if tokenType == html.StartTagToken && !p.seenTitle {
+			p.inTitle, p.seenTitle = true, true
+		}
+	case "base":
+		// Only the first <base> element with an href counts:
+		if href, ok := attrs["href"]; ok && !p.seenBase {
+			p.seenBase = true
+
+			if resolved, ok := resolve(p.base, href); ok {
+				p.doc.Base = resolved
+			}
+		}
+	case "a":
+		// Anchors cannot be nested, so a new anchor ends any open one:
+		p.anchor = -1
+
+		if p.addLink(&p.doc.Links, token, attrs, "href", attrs["href"]) && tokenType == html.StartTagToken {
+			p.anchor = len(p.doc.Links) - 1
+		}
+	case "area":
+		p.addLink(&p.doc.Links, token, attrs, "href", attrs["href"])
+	case "iframe", "frame":
+		p.addLink(&p.doc.Links, token, attrs, "src", attrs["src"])
+	case "link":
+		rel := relValues(attrs["rel"])
+
+		switch {
+		case containsAny(rel, resourceRels):
+			p.addLink(&p.doc.Resources, token, attrs, "href", attrs["href"])
+		case containsAny(rel, navigationRels):
+			if p.addLink(&p.doc.Links, token, attrs, "href", attrs["href"]) {
+				link := p.doc.Links[len(p.doc.Links)-1]
+
+				p.metaLink(link)
+
+				if slices.Contains(link.Rel, "alternate") && slices.Contains(feedTypes, link.Type) {
+					p.doc.Feeds = append(p.doc.Feeds, link)
+				}
+			}
+		}
+	case "meta":
+		if name := strings.ToLower(strings.TrimSpace(attrs["name"])); name != "" {
+			if p.doc.Meta == nil {
+				p.doc.Meta = make(map[string][]string)
+			}
+
+			p.doc.Meta[name] = append(p.doc.Meta[name], attrs["content"])
+		}
+
+		if strings.EqualFold(attrs["http-equiv"], "refresh") {
+			if target, ok := refreshURL(attrs["content"]); ok {
+				p.addLink(&p.doc.Links, token, attrs, "content", target)
+			}
+		}
+	default:
+		p.addResources(token, attrs)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// addResources extracts the resource links of an element, if it loads any.
+func (p *htmlParser) addResources(token html.Token, attrs map[string]string) {
+	for _, key := range resourceAttributes[token.Data] {
+		if key == "srcset" {
+			for _, candidate := range srcsetURLs(attrs[key]) {
+				p.addLink(&p.doc.Resources, token, attrs, key, candidate)
+			}
+
+			continue
+		}
+
+		p.addLink(&p.doc.Resources, token, attrs, key, attrs[key])
+	}
+}
+
+/*****************************************************************************************************************/
+
+// addLink resolves a URL found in an attribute of an element and appends it to the links when it is a valid
+// http(s) URL, reporting whether it was added.
+func (p *htmlParser) addLink(links *[]Link, token html.Token, attrs map[string]string, attribute, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		return false
+	}
+
+	resolved, ok := resolve(p.doc.Base, value)
+
+	if !ok {
+		return false
+	}
+
+	var region string
+
+	if len(p.regions) > 0 {
+		region = p.regions[len(p.regions)-1]
+	}
+
+	*links = append(*links, Link{
+		Href:      value,
+		URL:       resolved,
+		Title:     strings.TrimSpace(attrs["title"]),
+		Rel:       relValues(attrs["rel"]),
+		Hreflang:  strings.TrimSpace(attrs["hreflang"]),
+		Type:      mediaType(attrs["type"]),
+		Region:    region,
+		Element:   token.Data,
+		Attribute: attribute,
+		Attrs:     attrs,
+	})
+
+	return true
+}
+
+/*****************************************************************************************************************/
+
+// resolve resolves a URL against a base, reporting whether the result is a valid http(s) URL.
+func resolve(base, value string) (string, bool) {
+	resolved, err := validate.Ahref(base, value)
+
+	if err != nil || !strings.HasPrefix(resolved, "http") {
+		return "", false
+	}
+
+	return resolved, true
+}
+
+/*****************************************************************************************************************/
+
+// attributes returns the attributes of a tag as a map, keeping the first value of repeated attributes.
+func attributes(token html.Token) map[string]string {
+	attrs := make(map[string]string, len(token.Attr))
+
+	for _, a := range token.Attr {
+		if _, ok := attrs[a.Key]; !ok {
+			attrs[a.Key] = a.Val
+		}
+	}
+
+	return attrs
+}
+
+/*****************************************************************************************************************/
+
+// relValues splits a rel attribute into its lowercased, space-separated values.
+func relValues(rel string) []string {
+	values := strings.Fields(strings.ToLower(rel))
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
+
+/*****************************************************************************************************************/
+
+// containsAny checks if any of the values is one of the wanted values.
+func containsAny(values, wanted []string) bool {
+	for _, v := range values {
+		if slices.Contains(wanted, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*****************************************************************************************************************/
+
+// mediaType returns the lowercased media type of a type attribute without parameters, e.g., "text/html".
+func mediaType(value string) string {
+	value, _, _ = strings.Cut(value, ";")
+
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+/*****************************************************************************************************************/
+
+// refreshURL extracts the URL of a meta refresh content attribute, e.g., "5; url=/next".
+func refreshURL(content string) (string, bool) {
+	_, target, ok := strings.Cut(content, ";")
+
+	if !ok {
+		// A delay without a URL refreshes the page itself:
+		return "", false
+	}
+
+	target = strings.TrimSpace(target)
+
+	key, value, ok := strings.Cut(target, "=")
+
+	if ok && strings.EqualFold(strings.TrimSpace(key), "url") {
+		target = strings.TrimSpace(value)
+	}
+
+	// The URL may be quoted:
+	target = strings.Trim(target, `"'`)
+
+	return target, target != ""
+}
+
+/*****************************************************************************************************************/
+
+// srcsetURLs extracts the candidate URLs of a srcset attribute, e.g., "small.jpg 480w, large.jpg 1080w".
+func srcsetURLs(srcset string) []string {
+	var urls []string
+
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+
+	return urls
+}
+
+/*****************************************************************************************************************/
+
+// collapseWhitespace trims a string and replaces every run of whitespace with a single space.
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
 }
 
 /*****************************************************************************************************************/
@@ -9,6 +9,7 @@ package parse
 import (
 	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -42,10 +43,354 @@ func TestAhrefsFromHTML(t *testing.T) {
 	}
 
 	for i, href := range expected {
-		if result[i] != href {
-			t.Errorf("Expected href %s, got %s", href, result[i])
+		if result[i].URL != href {
+			t.Errorf("Expected href %s, got %s", href, result[i].URL)
 		}
 	}
 }
 
 /*****************************************************************************************************************/
+
+func TestAhrefsFromHTMLTranscodesCharset(t *testing.T) {
+	// "日本" in Shift_JIS, as the anchor text and percent-encoded in UTF-8 by the href:
+	body := `<html><head><meta charset="shift_jis"></head><body>` +
+		`<a href="/%E6%97%A5%E6%9C%AC">` + "\x93\xfa\x96\x7b" + `</a></body></html>`
+
+	result := AhrefsFromHTML(io.NopCloser(strings.NewReader(body)), "http://base.com")
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 href, got %d", len(result))
+	}
+
+	if result[0].Text != "日本" {
+		t.Errorf("Expected anchor text %q, got %q", "日本", result[0].Text)
+	}
+
+	if result[0].URL != "http://base.com/%E6%97%A5%E6%9C%AC" {
+		t.Errorf("Expected href %s, got %s", "http://base.com/%E6%97%A5%E6%9C%AC", result[0].URL)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTML(t *testing.T) {
+	fileContent, err := os.ReadFile("ahref_test.html")
+	if err != nil {
+		t.Fatalf("Failed to read HTML file: %v", err)
+	}
+
+	doc := DocumentFromHTML(strings.NewReader(string(fileContent)), "http://base.com")
+
+	if doc.Title != "Test Page" {
+		t.Errorf("Expected title %q, got %q", "Test Page", doc.Title)
+	}
+
+	if len(doc.Links) != 6 {
+		t.Errorf("Expected 6 links, got %d", len(doc.Links))
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLTitleWhitespace(t *testing.T) {
+	doc := DocumentFromHTML(strings.NewReader("<title>\n  Koroutine &amp;\n  Co  </title><svg><title>Icon</title></svg>"), "http://base.com")
+
+	if doc.Title != "Koroutine & Co" {
+		t.Errorf("Expected title %q, got %q", "Koroutine & Co", doc.Title)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLAnchorText(t *testing.T) {
+	body := `<a href="/about" rel="author" title="About us">About <b>the   team</b></a>
+		<a href="/one">One<a href="/two">Two</a>
+		<a href="javascript:void(0);">Ignored</a>`
+
+	doc := DocumentFromHTML(strings.NewReader(body), "http://base.com")
+
+	if len(doc.Links) != 3 {
+		t.Fatalf("Expected 3 links, got %d", len(doc.Links))
+	}
+
+	about := doc.Links[0]
+
+	if about.URL != "http://base.com/about" || about.Text != "About the team" {
+		t.Errorf("Unexpected link: %+v", about)
+	}
+
+	if about.Attrs["rel"] != "author" || about.Attrs["title"] != "About us" {
+		t.Errorf("Expected rel and title attributes, got %v", about.Attrs)
+	}
+
+	// An unclosed anchor ends when the next one starts:
+	if doc.Links[1].Text != "One" || doc.Links[2].Text != "Two" {
+		t.Errorf("Expected texts One and Two, got %q and %q", doc.Links[1].Text, doc.Links[2].Text)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLBaseHref(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		base     string
+		expected []string
+	}{
+		{
+			name:     "No base element",
+			body:     `<a href="page">Page</a>`,
+			base:     "https://koroutine.tech/docs/intro",
+			expected: []string{"https://koroutine.tech/docs/page"},
+		},
+		{
+			name:     "Absolute base href",
+			body:     `<head><base href="https://cdn.koroutine.tech/v2/"></head><a href="page">Page</a><a href="/root">Root</a>`,
+			base:     "https://koroutine.tech/docs/intro",
+			expected: []string{"https://cdn.koroutine.tech/v2/page", "https://cdn.koroutine.tech/root"},
+		},
+		{
+			name:     "Relative base href is resolved against the document URL",
+			body:     `<base href="../archive/"/><a href="page">Page</a>`,
+			base:     "https://koroutine.tech/docs/intro",
+			expected: []string{"https://koroutine.tech/archive/page"},
+		},
+		{
+			name:     "Only the first base element counts",
+			body:     `<base href="/first/"><base href="/second/"><a href="page">Page</a>`,
+			base:     "https://koroutine.tech/",
+			expected: []string{"https://koroutine.tech/first/page"},
+		},
+		{
+			name:     "Base without href is ignored",
+			body:     `<base target="_blank"><base href="/docs/"><a href="page">Page</a>`,
+			base:     "https://koroutine.tech/",
+			expected: []string{"https://koroutine.tech/docs/page"},
+		},
+		{
+			name:     "Links before the base element use the document URL",
+			body:     `<a href="before">Before</a><base href="/docs/"><a href="after">After</a>`,
+			base:     "https://koroutine.tech/",
+			expected: []string{"https://koroutine.tech/before", "https://koroutine.tech/docs/after"},
+		},
+	}
+
+	for _, tc := range tests {
+		doc := DocumentFromHTML(strings.NewReader(tc.body), tc.base)
+
+		var urls []string
+
+		for _, link := range doc.Links {
+			urls = append(urls, link.URL)
+		}
+
+		if !reflect.DeepEqual(urls, tc.expected) {
+			t.Errorf("Test %s failed: expected %v, got %v", tc.name, tc.expected, urls)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLElements(t *testing.T) {
+	body := `<html><head>
+		<link rel="canonical" href="/canonical">
+		<link rel="alternate" hreflang="de" href="/de/">
+		<link rel="next" href="/page/2">
+		<link rel="Stylesheet" href="/style.css">
+		<link rel="icon" href="/favicon.ico">
+		<link rel="preconnect" href="https://fonts.example.com">
+		<meta http-equiv="Refresh" content="5; URL='/refreshed'">
+		<script src="/app.js"></script>
+		<script>var inline = true;</script>
+	</head><body>
+		<a href="/about">About</a>
+		<map><area href="/area" alt="Area"></map>
+		<iframe src="/embed"></iframe>
+		<img src="/small.jpg" srcset="/small.jpg 480w, /large.jpg 1080w">
+		<picture><source srcset="/photo.webp"></picture>
+		<video poster="/poster.jpg" src="/movie.mp4"></video>
+		<form action="/search"></form>
+		<form></form>
+	</body></html>`
+
+	doc := DocumentFromHTML(strings.NewReader(body), "https://base.com/")
+
+	type result struct{ url, element, attribute string }
+
+	collect := func(links []Link) []result {
+		var results []result
+
+		for _, link := range links {
+			results = append(results, result{link.URL, link.Element, link.Attribute})
+		}
+
+		return results
+	}
+
+	expectedLinks := []result{
+		{"https://base.com/canonical", "link", "href"},
+		{"https://base.com/de/", "link", "href"},
+		{"https://base.com/page/2", "link", "href"},
+		{"https://base.com/refreshed", "meta", "content"},
+		{"https://base.com/about", "a", "href"},
+		{"https://base.com/area", "area", "href"},
+		{"https://base.com/embed", "iframe", "src"},
+	}
+
+	if links := collect(doc.Links); !reflect.DeepEqual(links, expectedLinks) {
+		t.Errorf("Expected links %v, got %v", expectedLinks, links)
+	}
+
+	expectedResources := []result{
+		{"https://base.com/style.css", "link", "href"},
+		{"https://base.com/favicon.ico", "link", "href"},
+		{"https://base.com/app.js", "script", "src"},
+		{"https://base.com/small.jpg", "img", "src"},
+		{"https://base.com/small.jpg", "img", "srcset"},
+		{"https://base.com/large.jpg", "img", "srcset"},
+		{"https://base.com/photo.webp", "source", "srcset"},
+		{"https://base.com/movie.mp4", "video", "src"},
+		{"https://base.com/poster.jpg", "video", "poster"},
+		{"https://base.com/search", "form", "action"},
+	}
+
+	if resources := collect(doc.Resources); !reflect.DeepEqual(resources, expectedResources) {
+		t.Errorf("Expected resources %v, got %v", expectedResources, resources)
+	}
+
+	if rel := doc.Links[1].Rel; !reflect.DeepEqual(rel, []string{"alternate"}) {
+		t.Errorf("Expected rel [alternate], got %v", rel)
+	}
+
+	if rel := doc.Resources[0].Rel; !reflect.DeepEqual(rel, []string{"stylesheet"}) {
+		t.Errorf("Expected lowercased rel [stylesheet], got %v", rel)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestRefreshURL(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+		ok       bool
+	}{
+		{content: "0; url=/next", expected: "/next", ok: true},
+		{content: "5;URL=https://example.com/", expected: "https://example.com/", ok: true},
+		{content: `3; url="/quoted"`, expected: "/quoted", ok: true},
+		{content: "0; /bare", expected: "/bare", ok: true},
+		{content: "30", expected: "", ok: false},
+		{content: "0; url=", expected: "", ok: false},
+	}
+
+	for _, tc := range tests {
+		target, ok := refreshURL(tc.content)
+
+		if target != tc.expected || ok != tc.ok {
+			t.Errorf("Test %s failed: expected %q %v, got %q %v", tc.content, tc.expected, tc.ok, target, ok)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLMeta(t *testing.T) {
+	body := `<head>
+		<meta charset="utf-8">
+		<meta name="Robots" content="noindex">
+		<meta name="robots" content="nofollow">
+		<meta name="koroutine-web-crawler" content="none">
+		<meta name="description" content="A description">
+	</head>`
+
+	doc := DocumentFromHTML(strings.NewReader(body), "https://base.com/")
+
+	expected := map[string][]string{
+		"robots":                {"noindex", "nofollow"},
+		"koroutine-web-crawler": {"none"},
+		"description":           {"A description"},
+	}
+
+	if !reflect.DeepEqual(doc.Meta, expected) {
+		t.Errorf("Expected meta %v, got %v", expected, doc.Meta)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestAhrefsFromHTMLLinkDetails(t *testing.T) {
+	body := `<header><nav>
+			<a href="/" title=" Home page ">Home</a>
+			<a href="../about">About</a>
+		</nav><a href="/logo"><img src="/logo.png" alt="Koroutine logo"></a></header>
+		<main>
+			<p>Read the <a href="/docs" rel="Help Author">docs <em>here</em></a>.</p>
+			<aside><a href="/de/" hreflang="de">Deutsch</a></aside>
+		</main>
+		</nav>
+		<footer><a href="https://external.com/" rel="nofollow sponsored">Sponsor</a></footer>
+		<a href="/outside">Outside</a>`
+
+	links := AhrefsFromHTML(io.NopCloser(strings.NewReader(body)), "https://base.com/docs/intro")
+
+	expected := []Link{
+		{Href: "/", URL: "https://base.com/", Text: "Home", Title: "Home page", Region: RegionNav},
+		{Href: "../about", URL: "https://base.com/about", Text: "About", Region: RegionNav},
+		{Href: "/logo", URL: "https://base.com/logo", Text: "Koroutine logo", Region: RegionHeader},
+		{Href: "/docs", URL: "https://base.com/docs", Text: "docs here", Rel: []string{"help", "author"}, Region: RegionMain},
+		{Href: "/de/", URL: "https://base.com/de/", Text: "Deutsch", Hreflang: "de", Region: RegionMain},
+		{Href: "https://external.com/", URL: "https://external.com/", Text: "Sponsor", Rel: []string{"nofollow", "sponsored"}, Region: RegionFooter},
+		{Href: "/outside", URL: "https://base.com/outside", Text: "Outside"},
+	}
+
+	if len(links) != len(expected) {
+		t.Fatalf("Expected %d links, got %d", len(expected), len(links))
+	}
+
+	for i, link := range links {
+		// Compare everything but the raw attributes:
+		link.Element, link.Attribute, link.Attrs = "", "", nil
+
+		if !reflect.DeepEqual(link, expected[i]) {
+			t.Errorf("Test %s failed: expected %+v, got %+v", expected[i].Href, expected[i], link)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLFeeds(t *testing.T) {
+	body := `<head>
+		<link rel="alternate" type="application/rss+xml" title="RSS" href="/feed.xml">
+		<link rel="alternate" type="Application/Atom+XML; charset=utf-8" href="https://koroutine.tech/atom.xml">
+		<link rel="alternate" hreflang="de" href="/de/">
+		<link rel="alternate stylesheet" type="application/rss+xml" href="/not-a-feed.css">
+	</head>`
+
+	doc := DocumentFromHTML(strings.NewReader(body), "https://koroutine.tech/")
+
+	var feeds []string
+
+	for _, feed := range doc.Feeds {
+		feeds = append(feeds, feed.URL)
+	}
+
+	expected := []string{"https://koroutine.tech/feed.xml", "https://koroutine.tech/atom.xml"}
+
+	if !reflect.DeepEqual(feeds, expected) {
+		t.Errorf("Expected feeds %v, got %v", expected, feeds)
+	}
+
+	if doc.Feeds[1].Type != "application/atom+xml" {
+		t.Errorf("Expected a lowercased media type, got %q", doc.Feeds[1].Type)
+	}
+
+	// Feeds are navigation links too, so the crawler follows them:
+	if len(doc.Links) != 3 {
+		t.Errorf("Expected 3 navigation links, got %d", len(doc.Links))
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,80 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+/*****************************************************************************************************************/
+
+// utf8SniffLength is the number of bytes checked for valid UTF-8 when a document does not declare its charset,
+// which is more than the 1024 bytes searched for a <meta charset>, as many pages only leave ASCII after their
+// head.
+const utf8SniffLength = 64 * 1024
+
+/*****************************************************************************************************************/
+
+// utf8BOM is the byte order mark which may start a UTF-8 document.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+/*****************************************************************************************************************/
+
+// NewUTF8Reader returns a reader transcoding a document to UTF-8, along with the name of its charset, e.g.,
+// "shift_jis". The charset is given by, in order: a byte order mark, the charset parameter of the Content-Type
+// header, a <meta charset> or <meta http-equiv="Content-Type"> element in the first 1024 bytes, or sniffing,
+// with undeclared documents which are valid UTF-8 read as UTF-8 and any others as windows-1252.
+func NewUTF8Reader(body io.Reader, contentType string) (io.Reader, string) {
+	reader := bufio.NewReaderSize(body, utf8SniffLength)
+
+	// Peek returns whatever is available alongside an error for bodies shorter than the sniff length:
+	peek, _ := reader.Peek(utf8SniffLength)
+
+	e, name, certain := charset.DetermineEncoding(peek, contentType)
+
+	// windows-1252 is also the fallback for undeclared documents whose first 1024 bytes are ASCII:
+	if !certain && name == "windows-1252" && validUTF8(peek) {
+		return reader, "utf-8"
+	}
+
+	if name == "utf-8" {
+		// The byte order mark is not part of the document, and would otherwise be read as text:
+		if bytes.HasPrefix(peek, utf8BOM) {
+			reader.Discard(len(utf8BOM))
+		}
+
+		return reader, name
+	}
+
+	return e.NewDecoder().Reader(reader), name
+}
+
+/*****************************************************************************************************************/
+
+// validUTF8 checks if a prefix of a document is valid UTF-8, ignoring a rune cut off at its end.
+func validUTF8(prefix []byte) bool {
+	for i := len(prefix) - 1; i >= 0 && i > len(prefix)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(prefix[i]) {
+			if !utf8.FullRune(prefix[i:]) {
+				prefix = prefix[:i]
+			}
+
+			break
+		}
+	}
+
+	return utf8.Valid(prefix)
+}
+
+/*****************************************************************************************************************/
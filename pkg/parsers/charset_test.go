@@ -0,0 +1,147 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+func TestNewUTF8Reader(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+		charset     string
+	}{
+		{
+			name:        "UTF-8 declared in the header",
+			contentType: "text/html; charset=utf-8",
+			body:        "<p>café</p>",
+			expected:    "<p>café</p>",
+			charset:     "utf-8",
+		},
+		{
+			name:        "ISO-8859-1 declared in the header",
+			contentType: "text/html; charset=ISO-8859-1",
+			body:        "<p>caf\xe9</p>",
+			expected:    "<p>café</p>",
+			charset:     "windows-1252",
+		},
+		{
+			name:        "Shift_JIS declared in a meta charset",
+			contentType: "text/html",
+			body:        `<meta charset="shift_jis"><p>` + "\x93\xfa\x96\x7b" + `</p>`,
+			expected:    `<meta charset="shift_jis"><p>日本</p>`,
+			charset:     "shift_jis",
+		},
+		{
+			name:     "Charset declared in a meta http-equiv",
+			body:     `<meta http-equiv="Content-Type" content="text/html; charset=koi8-r"><p>` + "\xd2\xd5\xd3" + `</p>`,
+			expected: `<meta http-equiv="Content-Type" content="text/html; charset=koi8-r"><p>рус</p>`,
+			charset:  "koi8-r",
+		},
+		{
+			name:        "Byte order mark overrides the header",
+			contentType: "text/html; charset=ISO-8859-1",
+			body:        "\xef\xbb\xbf<p>café</p>",
+			expected:    "<p>café</p>",
+			charset:     "utf-8",
+		},
+		{
+			name:     "Undeclared valid UTF-8 after the first 1024 bytes",
+			body:     "<p>" + strings.Repeat("a", 2048) + "café</p>",
+			expected: "<p>" + strings.Repeat("a", 2048) + "café</p>",
+			charset:  "utf-8",
+		},
+		{
+			name:     "Undeclared invalid UTF-8 falls back to windows-1252",
+			body:     "<p>caf\xe9 \x93quoted\x94</p>",
+			expected: "<p>café “quoted”</p>",
+			charset:  "windows-1252",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, charset := NewUTF8Reader(strings.NewReader(tc.body), tc.contentType)
+
+			body, err := io.ReadAll(reader)
+
+			if err != nil {
+				t.Fatalf("Test %s failed: unexpected error: %v", tc.name, err)
+			}
+
+			if charset != tc.charset {
+				t.Errorf("Test %s failed: expected charset %q, got %q", tc.name, tc.charset, charset)
+			}
+
+			if string(body) != tc.expected {
+				t.Errorf("Test %s failed: expected %q, got %q", tc.name, tc.expected, string(body))
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestValidUTF8IgnoresTruncatedRune(t *testing.T) {
+	// The first two bytes of "é" followed by the first of "日":
+	if !validUTF8([]byte("caf\xc3\xa9 \xe6")) {
+		t.Error("Expected a rune cut off at the end to be ignored")
+	}
+
+	if validUTF8([]byte("caf\xe9 ok")) {
+		t.Error("Expected an invalid byte before the end to be reported")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestFeedFromXMLTranscodesDeclaredEncoding(t *testing.T) {
+	body := `<?xml version="1.0" encoding="ISO-8859-1"?><rss version="2.0"><channel><title>Caf` + "\xe9" + `</title>` +
+		`<item><title>Cr` + "\xe8" + `me</title><link>https://koroutine.tech/creme</link></item></channel></rss>`
+
+	feed, err := FeedFromXML(strings.NewReader(body))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if feed.Title != "Café" {
+		t.Errorf("Expected title %q, got %q", "Café", feed.Title)
+	}
+
+	if len(feed.Items) != 1 || feed.Items[0].Title != "Crème" {
+		t.Errorf("Unexpected items: %+v", feed.Items)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromXMLTranscodesDeclaredEncoding(t *testing.T) {
+	body := `<?xml version="1.0" encoding="windows-1252"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+		`<url><loc>https://koroutine.tech/caf` + "\xe9" + `</loc></url></urlset>`
+
+	doc, err := DocumentFromXML(strings.NewReader(body), "https://koroutine.tech")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(doc.Links) != 1 || doc.Links[0].Href != "https://koroutine.tech/café" {
+		t.Errorf("Unexpected links: %+v", doc.Links)
+	}
+}
+
+/*****************************************************************************************************************/
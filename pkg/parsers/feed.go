@@ -0,0 +1,188 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+// maxFeedSize is the maximum size of a feed document which is parsed.
+const maxFeedSize = 10 * 1024 * 1024
+
+/*****************************************************************************************************************/
+
+// FeedItem is a single item of an RSS feed or entry of an Atom feed.
+type FeedItem struct {
+	URL   string
+	Title string
+	// Published is the publication date of the item, or the zero time when it has none or it is malformed.
+	Published time.Time
+}
+
+/*****************************************************************************************************************/
+
+// Feed is a parsed RSS 2.0, RSS 1.0 (RDF) or Atom feed.
+type Feed struct {
+	Title string
+	Items []FeedItem
+}
+
+/*****************************************************************************************************************/
+
+// feedLink is a <link> element, holding the URL as text in RSS and in the href attribute in Atom.
+type feedLink struct {
+	Text string `xml:",chardata"`
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+/*****************************************************************************************************************/
+
+// feedItem is the XML shape shared by RSS items and Atom entries.
+type feedItem struct {
+	Title string     `xml:"title"`
+	Links []feedLink `xml:"link"`
+	// Dates: <pubDate> in RSS 2.0, <dc:date> in RDF and <published> or <updated> in Atom:
+	PubDate   string `xml:"pubDate"`
+	Date      string `xml:"date"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	GUID      struct {
+		Value       string `xml:",chardata"`
+		IsPermaLink string `xml:"isPermaLink,attr"`
+	} `xml:"guid"`
+}
+
+/*****************************************************************************************************************/
+
+// feedDocument is the XML shape shared by RSS 2.0, RDF and Atom documents.
+type feedDocument struct {
+	XMLName xml.Name
+	// RSS 2.0 items are inside the channel, while RDF only has its title there:
+	Channel struct {
+		Title string     `xml:"title"`
+		Items []feedItem `xml:"item"`
+	} `xml:"channel"`
+	// RDF items are siblings of the channel:
+	Items []feedItem `xml:"item"`
+	// Atom feeds have their title and entries at the top level:
+	Title   string     `xml:"title"`
+	Entries []feedItem `xml:"entry"`
+}
+
+/*****************************************************************************************************************/
+
+// FeedFromXML parses an RSS 2.0, RSS 1.0 (RDF) or Atom feed. Item URLs are returned as written, so relative
+// URLs must still be resolved against the feed URL.
+func FeedFromXML(body io.Reader) (*Feed, error) {
+	var doc feedDocument
+
+	if err := newXMLDecoder(io.LimitReader(body, maxFeedSize)).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{}
+
+	var items []feedItem
+
+	switch doc.XMLName.Local {
+	case "rss":
+		feed.Title, items = doc.Channel.Title, doc.Channel.Items
+	case "RDF":
+		feed.Title, items = doc.Channel.Title, doc.Items
+	case "feed":
+		feed.Title, items = doc.Title, doc.Entries
+	default:
+		return nil, fmt.Errorf("unexpected feed root element <%s>", doc.XMLName.Local)
+	}
+
+	feed.Title = collapseWhitespace(feed.Title)
+
+	for _, item := range items {
+		if u := item.url(); u != "" {
+			feed.Items = append(feed.Items, FeedItem{
+				URL:       u,
+				Title:     collapseWhitespace(item.Title),
+				Published: item.published(),
+			})
+		}
+	}
+
+	return feed, nil
+}
+
+/*****************************************************************************************************************/
+
+// feedDateLayouts are the date formats found in feeds: RFC 822 variants in RSS 2.0, and RFC 3339 (or the W3C
+// profile of ISO 8601) in RDF and Atom.
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC3339Nano,
+	"2006-01-02T15:04-07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+/*****************************************************************************************************************/
+
+// published returns the publication date of an item, preferring the Atom <published> date over <updated>.
+func (item feedItem) published() time.Time {
+	for _, value := range []string{item.PubDate, item.Date, item.Published, item.Updated} {
+		value = strings.TrimSpace(value)
+
+		if value == "" {
+			continue
+		}
+
+		for _, layout := range feedDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+/*****************************************************************************************************************/
+
+// url returns the URL of an item: the text of an RSS <link>, the href of an Atom alternate <link>, or an RSS
+// <guid> which is a permalink.
+func (item feedItem) url() string {
+	for _, link := range item.Links {
+		if text := strings.TrimSpace(link.Text); text != "" {
+			return text
+		}
+
+		if href := strings.TrimSpace(link.Href); href != "" && (link.Rel == "" || link.Rel == "alternate") {
+			return href
+		}
+	}
+
+	guid := strings.TrimSpace(item.GUID.Value)
+
+	if item.GUID.IsPermaLink != "false" && strings.HasPrefix(guid, "http") {
+		return guid
+	}
+
+	return ""
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,147 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+const rssXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>Koroutine Blog</title>
+    <link>https://koroutine.tech/blog</link>
+    <atom:link href="https://koroutine.tech/feed.xml" rel="self" type="application/rss+xml"/>
+    <item>
+      <title>First post</title>
+      <link>https://koroutine.tech/blog/first</link>
+      <pubDate>Wed, 01 May 2024 12:00:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>Permalink only</title>
+      <pubDate>not a date</pubDate>
+      <guid>https://koroutine.tech/blog/second</guid>
+    </item>
+    <item>
+      <title>Not a permalink</title>
+      <guid isPermaLink="false">https://koroutine.tech/blog/ignored</guid>
+    </item>
+  </channel>
+</rss>`
+
+/*****************************************************************************************************************/
+
+const rdfXML = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+  <channel rdf:about="https://koroutine.tech/">
+    <title>Koroutine RDF</title>
+  </channel>
+  <item rdf:about="https://koroutine.tech/rdf/one">
+    <title>One</title>
+    <link>https://koroutine.tech/rdf/one</link>
+    <dc:date xmlns:dc="http://purl.org/dc/elements/1.1/">2024-05-02T08:30:00+01:00</dc:date>
+  </item>
+</rdf:RDF>`
+
+/*****************************************************************************************************************/
+
+const atomXML = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Koroutine Atom</title>
+  <link rel="self" href="https://koroutine.tech/atom.xml"/>
+  <entry>
+    <title>Entry   one</title>
+    <link rel="edit" href="https://koroutine.tech/edit/1"/>
+    <link rel="alternate" href="/atom/one"/>
+    <updated>2024-05-04T00:00:00Z</updated>
+    <published>2024-05-03T00:00:00Z</published>
+  </entry>
+  <entry>
+    <title>Entry two</title>
+    <link href="https://koroutine.tech/atom/two"/>
+    <updated>2024-05-05</updated>
+  </entry>
+</feed>`
+
+/*****************************************************************************************************************/
+
+func TestFeedFromXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		title    string
+		expected []FeedItem
+	}{
+		{
+			name:  "RSS 2.0",
+			body:  rssXML,
+			title: "Koroutine Blog",
+			expected: []FeedItem{
+				{URL: "https://koroutine.tech/blog/first", Title: "First post", Published: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+				{URL: "https://koroutine.tech/blog/second", Title: "Permalink only"},
+			},
+		},
+		{
+			name:     "RSS 1.0",
+			body:     rdfXML,
+			title:    "Koroutine RDF",
+			expected: []FeedItem{{URL: "https://koroutine.tech/rdf/one", Title: "One", Published: time.Date(2024, 5, 2, 7, 30, 0, 0, time.UTC)}},
+		},
+		{
+			name:  "Atom",
+			body:  atomXML,
+			title: "Koroutine Atom",
+			expected: []FeedItem{
+				{URL: "/atom/one", Title: "Entry one", Published: time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)},
+				{URL: "https://koroutine.tech/atom/two", Title: "Entry two", Published: time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		feed, err := FeedFromXML(strings.NewReader(tc.body))
+
+		if err != nil {
+			t.Fatalf("Test %s failed: unexpected error %v", tc.name, err)
+		}
+
+		if feed.Title != tc.title {
+			t.Errorf("Test %s failed: expected title %q, got %q", tc.name, tc.title, feed.Title)
+		}
+
+		if len(feed.Items) != len(tc.expected) {
+			t.Fatalf("Test %s failed: expected %d items, got %d", tc.name, len(tc.expected), len(feed.Items))
+		}
+
+		// Dates are compared as instants, as time zones are kept as parsed:
+		for i, item := range feed.Items {
+			expected := tc.expected[i]
+
+			if item.URL != expected.URL || item.Title != expected.Title || !item.Published.Equal(expected.Published) {
+				t.Errorf("Test %s failed: expected %+v, got %+v", tc.name, expected, item)
+			}
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestFeedFromXMLInvalid(t *testing.T) {
+	for _, body := range []string{`<html></html>`, `<rss><channel>`, ``} {
+		if _, err := FeedFromXML(strings.NewReader(body)); err == nil {
+			t.Errorf("Expected an error for %q", body)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
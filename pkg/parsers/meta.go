@@ -0,0 +1,195 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/*****************************************************************************************************************/
+
+// Heading is an h1–h6 element of a page's outline.
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+/*****************************************************************************************************************/
+
+// Alternate is a translation of a page, given by a <link rel="alternate" hreflang> element.
+type Alternate struct {
+	Hreflang string `json:"hreflang"`
+	URL      string `json:"url"`
+}
+
+/*****************************************************************************************************************/
+
+// PageMeta is the metadata of an HTML page, extracted in the same pass as its links.
+type PageMeta struct {
+	// Title is the text of the first <title> element, with whitespace collapsed.
+	Title string `json:"title,omitempty"`
+	// Description is the content of the first <meta name="description"> element.
+	Description string `json:"description,omitempty"`
+	// Keywords are the comma-separated values of the first <meta name="keywords"> element.
+	Keywords []string `json:"keywords,omitempty"`
+	// OpenGraph are the <meta property="og:*"> tags by property, e.g., "og:title", keeping the first of each.
+	OpenGraph map[string]string `json:"open_graph,omitempty"`
+	// Twitter are the <meta name="twitter:*"> card tags by name, e.g., "twitter:card", keeping the first of each.
+	Twitter map[string]string `json:"twitter,omitempty"`
+	// Canonical is the resolved href of the first <link rel="canonical"> element.
+	Canonical string `json:"canonical,omitempty"`
+	// Lang is the lang attribute of the <html> element, e.g., "en-GB".
+	Lang string `json:"lang,omitempty"`
+	// Hreflang are the translations of the page, in document order.
+	Hreflang []Alternate `json:"hreflang,omitempty"`
+	// Headings is the h1–h6 outline of the page, in document order.
+	Headings []Heading `json:"headings,omitempty"`
+	// WordCount is the number of words of visible text, i.e., excluding scripts, styles and the title.
+	WordCount int `json:"word_count"`
+}
+
+/*****************************************************************************************************************/
+
+// hiddenTextTags are the elements whose text is not visible on the page, so is not counted as words.
+var hiddenTextTags = []string{"script", "style", "noscript", "template", "textarea", "iframe", "noembed", "noframes"}
+
+/*****************************************************************************************************************/
+
+// headingLevel returns the level of an h1–h6 tag, or 0 for any other tag.
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+
+	return 0
+}
+
+/*****************************************************************************************************************/
+
+// metaText records the visible text of the page: its word count and the text of any open heading.
+func (p *htmlParser) metaText(text string) {
+	if p.hidden != "" || p.inTitle {
+		return
+	}
+
+	p.doc.Page.WordCount += len(strings.Fields(text))
+
+	if p.heading != -1 {
+		p.doc.Page.Headings[p.heading].Text += text
+	}
+}
+
+/*****************************************************************************************************************/
+
+// metaStartTag records the metadata of an element from its start tag.
+func (p *htmlParser) metaStartTag(tokenType html.TokenType, token html.Token, attrs map[string]string) {
+	page := p.doc.Page
+
+	if level := headingLevel(token.Data); level != 0 && tokenType == html.StartTagToken {
+		page.Headings = append(page.Headings, Heading{Level: level})
+		p.heading = len(page.Headings) - 1
+		return
+	}
+
+	switch token.Data {
+	case "html":
+		if page.Lang == "" {
+			page.Lang = strings.TrimSpace(attrs["lang"])
+		}
+	case "meta":
+		name := strings.ToLower(strings.TrimSpace(attrs["name"]))
+
+		// OpenGraph uses the property attribute, but twitter cards are commonly found under either:
+		if name == "" {
+			name = strings.ToLower(strings.TrimSpace(attrs["property"]))
+		}
+
+		content := strings.TrimSpace(attrs["content"])
+
+		switch {
+		case name == "description" && page.Description == "":
+			page.Description = content
+		case name == "keywords" && page.Keywords == nil:
+			for _, keyword := range strings.Split(content, ",") {
+				if keyword = strings.TrimSpace(keyword); keyword != "" {
+					page.Keywords = append(page.Keywords, keyword)
+				}
+			}
+		case strings.HasPrefix(name, "og:"):
+			page.OpenGraph = addFirst(page.OpenGraph, name, content)
+		case strings.HasPrefix(name, "twitter:"):
+			page.Twitter = addFirst(page.Twitter, name, content)
+		}
+	default:
+		if tokenType == html.StartTagToken && slices.Contains(hiddenTextTags, token.Data) {
+			p.hidden = token.Data
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+// metaEndTag records the end of an element.
+func (p *htmlParser) metaEndTag(token html.Token) {
+	if headingLevel(token.Data) != 0 {
+		p.heading = -1
+	}
+
+	if token.Data == p.hidden {
+		p.hidden = ""
+	}
+}
+
+/*****************************************************************************************************************/
+
+// metaLink records the metadata of a <link> element once its URL has been resolved.
+func (p *htmlParser) metaLink(link Link) {
+	page := p.doc.Page
+
+	if slices.Contains(link.Rel, "canonical") && page.Canonical == "" {
+		page.Canonical = link.URL
+	}
+
+	if slices.Contains(link.Rel, "alternate") && link.Hreflang != "" {
+		page.Hreflang = append(page.Hreflang, Alternate{Hreflang: link.Hreflang, URL: link.URL})
+	}
+}
+
+/*****************************************************************************************************************/
+
+// finishMeta tidies the metadata once the whole document has been read.
+func (p *htmlParser) finishMeta() {
+	page := p.doc.Page
+
+	page.Title = p.doc.Title
+
+	for i := range page.Headings {
+		page.Headings[i].Text = collapseWhitespace(page.Headings[i].Text)
+	}
+}
+
+/*****************************************************************************************************************/
+
+// addFirst sets a key of a map, creating the map if needed, unless the key is already set.
+func addFirst(m map[string]string, key, value string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+
+	if _, ok := m[key]; !ok {
+		m[key] = value
+	}
+
+	return m
+}
+
+/*****************************************************************************************************************/
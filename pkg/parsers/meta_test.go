@@ -0,0 +1,105 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+const metaHTML = `<!DOCTYPE html>
+<html lang="en-GB">
+<head>
+	<title> Koroutine   Tech </title>
+	<meta name="Description" content=" Web crawling in Go. ">
+	<meta name="description" content="A second description">
+	<meta name="keywords" content="go, crawler,, web ">
+	<meta property="og:title" content="Koroutine">
+	<meta property="og:image" content="https://koroutine.tech/one.png">
+	<meta property="og:image" content="https://koroutine.tech/two.png">
+	<meta name="twitter:card" content="summary">
+	<meta property="twitter:site" content="@koroutine">
+	<link rel="canonical" href="/home">
+	<link rel="alternate" hreflang="de" href="/de/">
+	<link rel="alternate" hreflang="x-default" href="https://koroutine.tech/">
+	<style>body { color: red; }</style>
+	<script>var words = "not counted";</script>
+</head>
+<body>
+	<h1>Welcome to <em>Koroutine</em></h1>
+	<p>Three words here.</p>
+	<h2>Section</h2>
+	<noscript>Enable JavaScript please</noscript>
+	<h3>Sub section</h3>
+</body>
+</html>`
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLPageMeta(t *testing.T) {
+	doc := DocumentFromHTML(strings.NewReader(metaHTML), "https://koroutine.tech/")
+
+	expected := &PageMeta{
+		Title:       "Koroutine Tech",
+		Description: "Web crawling in Go.",
+		Keywords:    []string{"go", "crawler", "web"},
+		OpenGraph: map[string]string{
+			"og:title": "Koroutine",
+			"og:image": "https://koroutine.tech/one.png",
+		},
+		Twitter: map[string]string{
+			"twitter:card": "summary",
+			"twitter:site": "@koroutine",
+		},
+		Canonical: "https://koroutine.tech/home",
+		Lang:      "en-GB",
+		Hreflang: []Alternate{
+			{Hreflang: "de", URL: "https://koroutine.tech/de/"},
+			{Hreflang: "x-default", URL: "https://koroutine.tech/"},
+		},
+		Headings: []Heading{
+			{Level: 1, Text: "Welcome to Koroutine"},
+			{Level: 2, Text: "Section"},
+			{Level: 3, Text: "Sub section"},
+		},
+		// Welcome to Koroutine (3) + Three words here. (3) + Section (1) + Sub section (2):
+		WordCount: 9,
+	}
+
+	if !reflect.DeepEqual(doc.Page, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, doc.Page)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLPageMetaEmpty(t *testing.T) {
+	doc := DocumentFromHTML(strings.NewReader(""), "https://koroutine.tech/")
+
+	if doc.Page == nil || !reflect.DeepEqual(*doc.Page, PageMeta{}) {
+		t.Errorf("Expected empty metadata, got %+v", doc.Page)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestHeadingLevel(t *testing.T) {
+	tests := map[string]int{"h1": 1, "h6": 6, "h7": 0, "h": 0, "hr": 0, "header": 0, "p": 0}
+
+	for tag, expected := range tests {
+		if level := headingLevel(tag); level != expected {
+			t.Errorf("Test %s failed: expected %d, got %d", tag, expected, level)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,157 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*****************************************************************************************************************/
+
+// Parser extracts a Document from a response body. The base is the URL the body was served from, which
+// relative links are resolved against.
+type Parser interface {
+	Parse(body io.Reader, base string) (*Document, error)
+}
+
+/*****************************************************************************************************************/
+
+// ParserFunc adapts an ordinary function to a Parser.
+type ParserFunc func(body io.Reader, base string) (*Document, error)
+
+/*****************************************************************************************************************/
+
+// Parse calls f(body, base).
+func (f ParserFunc) Parse(body io.Reader, base string) (*Document, error) {
+	return f(body, base)
+}
+
+/*****************************************************************************************************************/
+
+// Built-in parsers, registered by DefaultRegistry.
+var (
+	// HTMLParser extracts the links, resources and metadata of HTML documents, see DocumentFromHTML.
+	HTMLParser Parser = ParserFunc(func(body io.Reader, base string) (*Document, error) {
+		return DocumentFromHTML(body, base), nil
+	})
+
+	// XMLParser extracts the links of sitemaps and RSS/Atom feeds, see DocumentFromXML.
+	XMLParser Parser = ParserFunc(DocumentFromXML)
+
+	// TextParser extracts the links of plain text URL lists, see DocumentFromText.
+	TextParser Parser = ParserFunc(DocumentFromText)
+)
+
+/*****************************************************************************************************************/
+
+// Registry maps media types to the parsers handling them. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	parsers map[string]Parser
+}
+
+/*****************************************************************************************************************/
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{parsers: make(map[string]Parser)}
+}
+
+/*****************************************************************************************************************/
+
+// DefaultRegistry creates a Registry with the built-in parsers for HTML, XML sitemaps, RSS/Atom feeds and plain
+// text URL lists. Each call returns a new Registry, so registering further parsers never affects another.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+
+	r.Register("text/html", HTMLParser)
+	r.Register("application/xhtml+xml", HTMLParser)
+
+	for _, mediaType := range []string{
+		"application/xml", "text/xml", "application/rss+xml", "application/atom+xml", "application/rdf+xml",
+	} {
+		r.Register(mediaType, XMLParser)
+	}
+
+	r.Register("text/plain", TextParser)
+
+	return r
+}
+
+/*****************************************************************************************************************/
+
+// Register sets the parser of a media type, e.g., "application/json", replacing any existing one. A media type
+// of the form "type/*" handles every subtype without a parser of its own.
+func (r *Registry) Register(mediaType string, parser Parser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.parsers[strings.ToLower(mediaType)] = parser
+}
+
+/*****************************************************************************************************************/
+
+// Clone returns a copy of the registry, which parsers can be registered with without affecting the original.
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := NewRegistry()
+
+	for mediaType, parser := range r.parsers {
+		clone.parsers[mediaType] = parser
+	}
+
+	return clone
+}
+
+/*****************************************************************************************************************/
+
+// Lookup returns the parser of a media type without parameters, falling back to a "type/*" parser.
+func (r *Registry) Lookup(mediaType string) (Parser, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mediaType = strings.ToLower(mediaType)
+
+	if parser, ok := r.parsers[mediaType]; ok {
+		return parser, true
+	}
+
+	if kind, _, ok := strings.Cut(mediaType, "/"); ok {
+		if parser, ok := r.parsers[kind+"/*"]; ok {
+			return parser, true
+		}
+	}
+
+	return nil, false
+}
+
+/*****************************************************************************************************************/
+
+// MediaTypes returns the registered media types, sorted.
+func (r *Registry) MediaTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mediaTypes := make([]string, 0, len(r.parsers))
+
+	for mediaType := range r.parsers {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+
+	sort.Strings(mediaTypes)
+
+	return mediaTypes
+}
+
+/*****************************************************************************************************************/
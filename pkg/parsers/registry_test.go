@@ -0,0 +1,115 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+func TestDefaultRegistryLookup(t *testing.T) {
+	r := DefaultRegistry()
+
+	tests := []struct {
+		mediaType string
+		found     bool
+	}{
+		{mediaType: "text/html", found: true},
+		{mediaType: "Application/XHTML+XML", found: true},
+		{mediaType: "application/xml", found: true},
+		{mediaType: "text/xml", found: true},
+		{mediaType: "application/rss+xml", found: true},
+		{mediaType: "application/atom+xml", found: true},
+		{mediaType: "text/plain", found: true},
+		{mediaType: "application/pdf", found: false},
+		{mediaType: "image/png", found: false},
+	}
+
+	for _, tc := range tests {
+		if _, found := r.Lookup(tc.mediaType); found != tc.found {
+			t.Errorf("Test %s failed: expected found %v, got %v", tc.mediaType, tc.found, found)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestRegistryRegister(t *testing.T) {
+	jsonParser := ParserFunc(func(body io.Reader, base string) (*Document, error) {
+		return &Document{Base: base, Links: []Link{{URL: base + "next"}}}, nil
+	})
+
+	r := DefaultRegistry()
+
+	r.Register("application/json", jsonParser)
+
+	parser, ok := r.Lookup("application/json")
+
+	if !ok {
+		t.Fatalf("Expected a parser for application/json")
+	}
+
+	doc, err := parser.Parse(strings.NewReader("{}"), "https://koroutine.tech/")
+
+	if err != nil || len(doc.Links) != 1 || doc.Links[0].URL != "https://koroutine.tech/next" {
+		t.Errorf("Expected the registered parser to be used, got %+v, %v", doc, err)
+	}
+
+	// Registering on one registry never affects another:
+	if _, ok := DefaultRegistry().Lookup("application/json"); ok {
+		t.Errorf("Expected a new default registry to be unaffected")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestRegistryClone(t *testing.T) {
+	r := DefaultRegistry()
+
+	clone := r.Clone()
+
+	clone.Register("application/json", TextParser)
+
+	if _, ok := clone.Lookup("text/html"); !ok {
+		t.Errorf("Expected the clone to keep the parsers of the original")
+	}
+
+	if _, ok := r.Lookup("application/json"); ok {
+		t.Errorf("Expected the original to be unaffected by registering with the clone")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestRegistryWildcard(t *testing.T) {
+	r := NewRegistry()
+
+	r.Register("image/*", TextParser)
+	r.Register("image/svg+xml", XMLParser)
+
+	if _, ok := r.Lookup("image/png"); !ok {
+		t.Errorf("Expected image/png to fall back to image/*")
+	}
+
+	if _, ok := r.Lookup("text/html"); ok {
+		t.Errorf("Expected no parser for text/html in an empty registry")
+	}
+
+	expected := []string{"image/*", "image/svg+xml"}
+
+	if mediaTypes := r.MediaTypes(); !reflect.DeepEqual(mediaTypes, expected) {
+		t.Errorf("Expected media types %v, got %v", expected, mediaTypes)
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,105 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*****************************************************************************************************************/
+
+// maxSitemapSize is the maximum uncompressed size of a sitemap, as set by the sitemaps.org protocol.
+const maxSitemapSize = 50 * 1024 * 1024
+
+/*****************************************************************************************************************/
+
+// SitemapURL is a single <url> entry of a urlset sitemap.
+type SitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+/*****************************************************************************************************************/
+
+// Sitemap is a parsed sitemap document: a urlset listing pages, or a sitemapindex listing further sitemaps.
+type Sitemap struct {
+	URLs     []SitemapURL
+	Sitemaps []string
+}
+
+/*****************************************************************************************************************/
+
+// sitemapDocument is the XML shape shared by urlset and sitemapindex documents.
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []SitemapURL `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+/*****************************************************************************************************************/
+
+// SitemapFromXML parses a urlset or sitemapindex document, transparently decompressing gzipped sitemaps.
+func SitemapFromXML(body io.Reader) (*Sitemap, error) {
+	reader := bufio.NewReader(body)
+
+	// Gzipped sitemaps are detected by their magic number rather than by file extension or header:
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer gz.Close()
+
+		body = gz
+	} else {
+		body = reader
+	}
+
+	var doc sitemapDocument
+
+	if err := newXMLDecoder(io.LimitReader(body, maxSitemapSize)).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	sitemap := &Sitemap{}
+
+	switch doc.XMLName.Local {
+	case "urlset":
+		for _, u := range doc.URLs {
+			u.Loc = strings.TrimSpace(u.Loc)
+
+			if u.Loc != "" {
+				sitemap.URLs = append(sitemap.URLs, u)
+			}
+		}
+	case "sitemapindex":
+		for _, s := range doc.Sitemaps {
+			if loc := strings.TrimSpace(s.Loc); loc != "" {
+				sitemap.Sitemaps = append(sitemap.Sitemaps, loc)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected sitemap root element <%s>", doc.XMLName.Local)
+	}
+
+	return sitemap, nil
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,129 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+const urlsetXML = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://koroutine.tech/</loc>
+    <lastmod>2024-05-01</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>1.0</priority>
+  </url>
+  <url>
+    <loc>
+      https://koroutine.tech/orphan
+    </loc>
+  </url>
+  <url>
+    <loc></loc>
+  </url>
+</urlset>`
+
+/*****************************************************************************************************************/
+
+const sitemapIndexXML = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://koroutine.tech/sitemap-pages.xml</loc>
+  </sitemap>
+  <sitemap>
+    <loc>https://koroutine.tech/sitemap-posts.xml.gz</loc>
+  </sitemap>
+</sitemapindex>`
+
+/*****************************************************************************************************************/
+
+func TestSitemapFromXMLURLSet(t *testing.T) {
+	sitemap, err := SitemapFromXML(strings.NewReader(urlsetXML))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(sitemap.URLs) != 2 {
+		t.Fatalf("Expected 2 URLs, got %d", len(sitemap.URLs))
+	}
+
+	if sitemap.URLs[0].Loc != "https://koroutine.tech/" || sitemap.URLs[0].LastMod != "2024-05-01" {
+		t.Errorf("Unexpected first URL: %+v", sitemap.URLs[0])
+	}
+
+	if sitemap.URLs[1].Loc != "https://koroutine.tech/orphan" {
+		t.Errorf("Expected whitespace to be trimmed, got %q", sitemap.URLs[1].Loc)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestSitemapFromXMLIndex(t *testing.T) {
+	sitemap, err := SitemapFromXML(strings.NewReader(sitemapIndexXML))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"https://koroutine.tech/sitemap-pages.xml",
+		"https://koroutine.tech/sitemap-posts.xml.gz",
+	}
+
+	if len(sitemap.Sitemaps) != len(expected) {
+		t.Fatalf("Expected %d sitemaps, got %d", len(expected), len(sitemap.Sitemaps))
+	}
+
+	for i, loc := range expected {
+		if sitemap.Sitemaps[i] != loc {
+			t.Errorf("Expected sitemap %s, got %s", loc, sitemap.Sitemaps[i])
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestSitemapFromXMLGzip(t *testing.T) {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(urlsetXML))
+	gz.Close()
+
+	sitemap, err := SitemapFromXML(&buf)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(sitemap.URLs) != 2 {
+		t.Errorf("Expected 2 URLs, got %d", len(sitemap.URLs))
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestSitemapFromXMLInvalid(t *testing.T) {
+	if _, err := SitemapFromXML(strings.NewReader(`<html><body>Not a sitemap</body></html>`)); err == nil {
+		t.Error("Expected an error for a non-sitemap document")
+	}
+
+	if _, err := SitemapFromXML(strings.NewReader(`not xml at all`)); err == nil {
+		t.Error("Expected an error for a non-XML document")
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,368 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/*****************************************************************************************************************/
+
+// JSONLD is a single <script type="application/ld+json"> block, decoded into generic JSON values, i.e.,
+// map[string]any, []any, string, float64, bool or nil.
+type JSONLD struct {
+	Data any `json:"data,omitempty"`
+	// Error is the reason the block could not be decoded, empty when it was.
+	Error string `json:"error,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// MicrodataItem is an element with an itemscope attribute. Property values are strings, or *MicrodataItem for
+// properties which are items themselves.
+type MicrodataItem struct {
+	Type       []string         `json:"type,omitempty"`
+	ID         string           `json:"id,omitempty"`
+	Properties map[string][]any `json:"properties,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// StructuredData is the schema.org markup of a page.
+type StructuredData struct {
+	JSONLD    []JSONLD         `json:"json_ld,omitempty"`
+	Microdata []*MicrodataItem `json:"microdata,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// schemaPrefixes are the prefixes stripped from types, so that "https://schema.org/Product", "schema:Product"
+// and "Product" are all reported as "Product".
+var schemaPrefixes = []string{"https://schema.org/", "http://schema.org/", "schema:"}
+
+/*****************************************************************************************************************/
+
+// Types returns the distinct types of all JSON-LD nodes and microdata items, including nested ones, sorted.
+func (s *StructuredData) Types() []string {
+	seen := make(map[string]bool)
+
+	for _, block := range s.JSONLD {
+		jsonLDTypes(block.Data, seen)
+	}
+
+	for _, item := range s.Microdata {
+		microdataTypes(item, seen)
+	}
+
+	types := make([]string, 0, len(seen))
+
+	for t := range seen {
+		types = append(types, t)
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+/*****************************************************************************************************************/
+
+// jsonLDTypes collects the @type values of a JSON-LD value and every value nested in it, e.g., in @graph.
+func jsonLDTypes(value any, seen map[string]bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		switch t := v["@type"].(type) {
+		case string:
+			seen[schemaType(t)] = true
+		case []any:
+			for _, each := range t {
+				if s, ok := each.(string); ok {
+					seen[schemaType(s)] = true
+				}
+			}
+		}
+
+		for key, nested := range v {
+			if key != "@type" {
+				jsonLDTypes(nested, seen)
+			}
+		}
+	case []any:
+		for _, nested := range v {
+			jsonLDTypes(nested, seen)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+// microdataTypes collects the types of a microdata item and every item nested in its properties.
+func microdataTypes(item *MicrodataItem, seen map[string]bool) {
+	for _, t := range item.Type {
+		seen[schemaType(t)] = true
+	}
+
+	for _, values := range item.Properties {
+		for _, value := range values {
+			if nested, ok := value.(*MicrodataItem); ok {
+				microdataTypes(nested, seen)
+			}
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+// schemaType strips the schema.org prefix of a type.
+func schemaType(t string) string {
+	t = strings.TrimSpace(t)
+
+	for _, prefix := range schemaPrefixes {
+		if strings.HasPrefix(t, prefix) {
+			return strings.TrimPrefix(t, prefix)
+		}
+	}
+
+	return t
+}
+
+/*****************************************************************************************************************/
+
+// microdataFrame is an open element with an itemscope or a text-valued itemprop attribute.
+type microdataFrame struct {
+	tag string
+	// item is set for elements with an itemscope attribute:
+	item *MicrodataItem
+	// props and owner are set for elements whose text content is the value of the owner's properties:
+	props []string
+	owner *MicrodataItem
+	text  strings.Builder
+	// nested counts the open elements of the same tag inside it without a frame of their own, whose end tags do
+	// not close it:
+	nested int
+}
+
+/*****************************************************************************************************************/
+
+// microdataVoidTags are the elements without an end tag which may carry an itemprop.
+var microdataVoidTags = []string{"meta", "link", "img", "source", "embed", "track", "area", "br", "hr", "input"}
+
+/*****************************************************************************************************************/
+
+// microdataURLAttributes are the attributes holding the value of an itemprop as a URL, by element.
+var microdataURLAttributes = map[string]string{
+	"a": "href", "area": "href", "link": "href",
+	"img": "src", "audio": "src", "video": "src", "source": "src", "iframe": "src", "embed": "src", "track": "src",
+	"object": "data",
+}
+
+/*****************************************************************************************************************/
+
+// structuredText records the text of an open JSON-LD block or text-valued itemprops.
+func (p *htmlParser) structuredText(text string) {
+	if p.jsonLD != nil {
+		p.jsonLD.WriteString(text)
+	}
+
+	for _, frame := range p.microdata {
+		if frame.props != nil {
+			frame.text.WriteString(text)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+// structuredStartTag records the structured data of an element from its start tag.
+func (p *htmlParser) structuredStartTag(tokenType html.TokenType, token html.Token, attrs map[string]string) {
+	if token.Data == "script" && tokenType == html.StartTagToken && mediaType(attrs["type"]) == "application/ld+json" {
+		p.jsonLD = &strings.Builder{}
+	}
+
+	_, scope := attrs["itemscope"]
+
+	props := strings.Fields(attrs["itemprop"])
+
+	// Elements without an end tag cannot be tracked as open:
+	void := tokenType == html.SelfClosingTagToken || slices.Contains(microdataVoidTags, token.Data)
+
+	if !scope && len(props) == 0 {
+		if frame := p.openFrame(token.Data); frame != nil && !void {
+			frame.nested++
+		}
+
+		return
+	}
+
+	owner := p.currentItem()
+
+	if scope {
+		item := &MicrodataItem{Type: strings.Fields(attrs["itemtype"]), ID: strings.TrimSpace(attrs["itemid"])}
+
+		if len(props) > 0 && owner != nil {
+			owner.add(props, item)
+		} else {
+			p.doc.Structured.Microdata = append(p.doc.Structured.Microdata, item)
+		}
+
+		if !void {
+			p.microdata = append(p.microdata, &microdataFrame{tag: token.Data, item: item})
+		}
+
+		return
+	}
+
+	// An itemprop outside of any item has no owner, so is ignored:
+	if owner == nil {
+		return
+	}
+
+	if value, ok := p.microdataAttributeValue(token.Data, attrs); ok {
+		owner.add(props, value)
+		return
+	}
+
+	if void {
+		owner.add(props, "")
+		return
+	}
+
+	p.microdata = append(p.microdata, &microdataFrame{tag: token.Data, props: props, owner: owner})
+}
+
+/*****************************************************************************************************************/
+
+// structuredEndTag records the end of an element, completing any JSON-LD block or microdata property.
+func (p *htmlParser) structuredEndTag(token html.Token) {
+	if token.Data == "script" && p.jsonLD != nil {
+		block := JSONLD{}
+
+		if err := json.Unmarshal([]byte(p.jsonLD.String()), &block.Data); err != nil {
+			block.Error = err.Error()
+		}
+
+		p.doc.Structured.JSONLD = append(p.doc.Structured.JSONLD, block)
+		p.jsonLD = nil
+	}
+
+	// Close the innermost open frame of the same element, and any left open inside it, unless the end tag is that
+	// of an element nested inside it:
+	for i := len(p.microdata) - 1; i >= 0; i-- {
+		if p.microdata[i].tag != token.Data {
+			continue
+		}
+
+		if p.microdata[i].nested > 0 {
+			p.microdata[i].nested--
+			return
+		}
+
+		for _, frame := range p.microdata[i:] {
+			if frame.props != nil {
+				frame.owner.add(frame.props, collapseWhitespace(frame.text.String()))
+			}
+		}
+
+		p.microdata = p.microdata[:i]
+
+		break
+	}
+}
+
+/*****************************************************************************************************************/
+
+// openFrame returns the innermost open microdata frame of an element, or nil if there is none.
+func (p *htmlParser) openFrame(tag string) *microdataFrame {
+	for i := len(p.microdata) - 1; i >= 0; i-- {
+		if p.microdata[i].tag == tag {
+			return p.microdata[i]
+		}
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// currentItem returns the innermost open microdata item, or nil outside of all items.
+func (p *htmlParser) currentItem() *MicrodataItem {
+	for i := len(p.microdata) - 1; i >= 0; i-- {
+		if p.microdata[i].item != nil {
+			return p.microdata[i].item
+		}
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// microdataAttributeValue returns the value of an itemprop given by an attribute rather than the text content,
+// e.g., the content of a <meta> or the resolved href of a <link>.
+func (p *htmlParser) microdataAttributeValue(tag string, attrs map[string]string) (string, bool) {
+	switch tag {
+	case "meta":
+		return strings.TrimSpace(attrs["content"]), true
+	case "time":
+		if datetime, ok := attrs["datetime"]; ok {
+			return strings.TrimSpace(datetime), true
+		}
+
+		return "", false
+	case "data", "meter":
+		return strings.TrimSpace(attrs["value"]), true
+	}
+
+	if key, ok := microdataURLAttributes[tag]; ok {
+		if resolved, ok := resolve(p.doc.Base, attrs[key]); ok {
+			return resolved, true
+		}
+
+		return strings.TrimSpace(attrs[key]), true
+	}
+
+	return "", false
+}
+
+/*****************************************************************************************************************/
+
+// finishStructured completes any elements left open at the end of the document, and drops empty results.
+func (p *htmlParser) finishStructured() {
+	for _, frame := range p.microdata {
+		if frame.props != nil {
+			frame.owner.add(frame.props, collapseWhitespace(frame.text.String()))
+		}
+	}
+
+	p.microdata = nil
+
+	if len(p.doc.Structured.JSONLD) == 0 && len(p.doc.Structured.Microdata) == 0 {
+		p.doc.Structured = nil
+	}
+}
+
+/*****************************************************************************************************************/
+
+// add appends a value to each of the named properties of an item.
+func (item *MicrodataItem) add(props []string, value any) {
+	if item.Properties == nil {
+		item.Properties = make(map[string][]any)
+	}
+
+	for _, prop := range props {
+		item.Properties[prop] = append(item.Properties[prop], value)
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,171 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+const structuredHTML = `<html><head>
+	<script type="application/ld+json">
+	{
+		"@context": "https://schema.org",
+		"@graph": [
+			{"@type": "Organization", "name": "Koroutine"},
+			{"@type": ["WebSite", "schema:CreativeWork"], "publisher": {"@type": "Person", "name": "Michael"}}
+		]
+	}
+	</script>
+	<script type="application/ld+json">{ "@type": "Broken", </script>
+	<script>var notJSONLD = true;</script>
+</head><body>
+	<div itemscope itemtype="https://schema.org/Product" itemid="urn:sku:42">
+		<h1 itemprop="name">Web <b>Crawler</b></h1>
+		<img itemprop="image" src="/crawler.png" alt="Crawler">
+		<meta itemprop="sku" content="42">
+		<div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+			<span itemprop="price priceSpecification">9.99</span>
+			<time itemprop="validFrom" datetime="2024-05-01">May</time>
+			<a itemprop="url" href="/buy">Buy</a>
+		</div>
+		<span itemprop="description">Fast</span>
+	</div>
+	<p itemprop="orphan">No item</p>
+</body></html>`
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLJSONLD(t *testing.T) {
+	doc := DocumentFromHTML(strings.NewReader(structuredHTML), "https://koroutine.tech/")
+
+	if doc.Structured == nil || len(doc.Structured.JSONLD) != 2 {
+		t.Fatalf("Expected 2 JSON-LD blocks, got %+v", doc.Structured)
+	}
+
+	valid := doc.Structured.JSONLD[0]
+
+	if valid.Error != "" {
+		t.Errorf("Expected the first block to decode, got %s", valid.Error)
+	}
+
+	if context := valid.Data.(map[string]any)["@context"]; context != "https://schema.org" {
+		t.Errorf("Expected the @context to be decoded, got %v", context)
+	}
+
+	// A broken block is reported without affecting the others:
+	broken := doc.Structured.JSONLD[1]
+
+	if broken.Error == "" || broken.Data != nil {
+		t.Errorf("Expected the second block to report an error, got %+v", broken)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLMicrodata(t *testing.T) {
+	doc := DocumentFromHTML(strings.NewReader(structuredHTML), "https://koroutine.tech/")
+
+	offer := &MicrodataItem{
+		Type: []string{"http://schema.org/Offer"},
+		Properties: map[string][]any{
+			"price":              {"9.99"},
+			"priceSpecification": {"9.99"},
+			"validFrom":          {"2024-05-01"},
+			"url":                {"https://koroutine.tech/buy"},
+		},
+	}
+
+	expected := []*MicrodataItem{
+		{
+			Type: []string{"https://schema.org/Product"},
+			ID:   "urn:sku:42",
+			Properties: map[string][]any{
+				"name":        {"Web Crawler"},
+				"image":       {"https://koroutine.tech/crawler.png"},
+				"sku":         {"42"},
+				"offers":      {offer},
+				"description": {"Fast"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(doc.Structured.Microdata, expected) {
+		got, _ := json.Marshal(doc.Structured.Microdata)
+		t.Errorf("Unexpected microdata: %s", got)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLMicrodataNestedElements(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected map[string][]any
+	}{
+		{
+			name:     "Plain element nested in an item",
+			html:     `<div itemscope><div class="x"><span itemprop="name">A</span></div><span itemprop="price">1</span></div>`,
+			expected: map[string][]any{"name": {"A"}, "price": {"1"}},
+		},
+		{
+			name:     "Plain element nested in a text property",
+			html:     `<div itemscope><span itemprop="name"><span>Web</span> Crawler</span><span itemprop="sku">42</span></div>`,
+			expected: map[string][]any{"name": {"Web Crawler"}, "sku": {"42"}},
+		},
+		{
+			name:     "Property element nested in a plain element",
+			html:     `<div itemscope><div><div itemprop="name">A</div></div><div itemprop="sku">42</div></div>`,
+			expected: map[string][]any{"name": {"A"}, "sku": {"42"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := DocumentFromHTML(strings.NewReader(tc.html), "https://koroutine.tech/")
+
+			if doc.Structured == nil || len(doc.Structured.Microdata) != 1 {
+				t.Fatalf("Test %s failed: expected 1 item, got %+v", tc.name, doc.Structured)
+			}
+
+			if got := doc.Structured.Microdata[0].Properties; !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Test %s failed: expected %v, got %v", tc.name, tc.expected, got)
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestStructuredDataTypes(t *testing.T) {
+	doc := DocumentFromHTML(strings.NewReader(structuredHTML), "https://koroutine.tech/")
+
+	expected := []string{"CreativeWork", "Offer", "Organization", "Person", "Product", "WebSite"}
+
+	if types := doc.Structured.Types(); !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected types %v, got %v", expected, types)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromHTMLWithoutStructuredData(t *testing.T) {
+	doc := DocumentFromHTML(strings.NewReader(`<p itemprop="name">No item</p>`), "https://koroutine.tech/")
+
+	if doc.Structured != nil {
+		t.Errorf("Expected no structured data, got %+v", doc.Structured)
+	}
+}
+
+/*****************************************************************************************************************/
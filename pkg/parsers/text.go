@@ -0,0 +1,50 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"strings"
+)
+
+/*****************************************************************************************************************/
+
+// DocumentFromText extracts the links of a plain text URL list with one absolute http(s) URL per line. Every other
+// line, e.g., blank lines, "#" comments and prose, is skipped, so ordinary text files yield no links.
+func DocumentFromText(body io.Reader, base string) (*Document, error) {
+	doc := &Document{Base: base}
+
+	scanner := bufio.NewScanner(body)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.ContainsAny(line, " \t") {
+			continue
+		}
+
+		u, err := url.Parse(line)
+
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+
+		doc.Links = append(doc.Links, Link{Href: line, URL: u.String()})
+	}
+
+	return doc, scanner.Err()
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,48 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromText(t *testing.T) {
+	body := `# URLs to crawl
+https://koroutine.tech/one
+
+  HTTPS://koroutine.tech/two  
+/relative/path
+ftp://koroutine.tech/file
+This line is prose, not a URL.
+https://koroutine.tech/three`
+
+	doc, err := DocumentFromText(strings.NewReader(body), "https://koroutine.tech/urls.txt")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var urls []string
+
+	for _, link := range doc.Links {
+		urls = append(urls, link.URL)
+	}
+
+	expected := []string{"https://koroutine.tech/one", "https://koroutine.tech/two", "https://koroutine.tech/three"}
+
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected %v, got %v", expected, urls)
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,122 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html/charset"
+)
+
+/*****************************************************************************************************************/
+
+// DocumentFromXML extracts the links of an XML document, telling sitemaps and feeds apart by their root element:
+// the pages and sitemaps of a urlset or sitemapindex, or the items of an RSS or Atom feed, which are also returned
+// as entries with their titles and publication dates. Links are resolved against the base, and any other root
+// element is an error.
+func DocumentFromXML(body io.Reader, base string) (*Document, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxSitemapSize))
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := rootElement(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Base: base}
+
+	switch root {
+	case "urlset", "sitemapindex":
+		sitemap, err := SitemapFromXML(bytes.NewReader(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range sitemap.URLs {
+			doc.addLink("url", "loc", u.Loc, "")
+		}
+
+		for _, loc := range sitemap.Sitemaps {
+			doc.addLink("sitemap", "loc", loc, "")
+		}
+	case "rss", "RDF", "feed":
+		feed, err := FeedFromXML(bytes.NewReader(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		doc.Title = feed.Title
+
+		for _, item := range feed.Items {
+			doc.addLink("item", "link", item.URL, item.Title)
+
+			// Entries carry the resolved URL, matching the links:
+			if resolved, ok := resolve(base, item.URL); ok {
+				item.URL = resolved
+				doc.Entries = append(doc.Entries, item)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported XML root element <%s>", root)
+	}
+
+	return doc, nil
+}
+
+/*****************************************************************************************************************/
+
+// addLink resolves a URL found in an element of an XML document and appends it to the links when it is a
+// valid http(s) URL.
+func (doc *Document) addLink(element, attribute, href, text string) {
+	if resolved, ok := resolve(doc.Base, href); ok {
+		doc.Links = append(doc.Links, Link{Href: href, URL: resolved, Text: text, Element: element, Attribute: attribute})
+	}
+}
+
+/*****************************************************************************************************************/
+
+// newXMLDecoder creates an XML decoder which transcodes documents declaring a charset other than UTF-8, e.g.,
+// <?xml version="1.0" encoding="ISO-8859-1"?>, to UTF-8.
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	return decoder
+}
+
+/*****************************************************************************************************************/
+
+// rootElement returns the local name of the root element of an XML document.
+func rootElement(data []byte) (string, error) {
+	decoder := newXMLDecoder(bytes.NewReader(data))
+
+	for {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return "", err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+/*****************************************************************************************************************/
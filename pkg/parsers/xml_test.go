@@ -0,0 +1,109 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package parse
+
+/*****************************************************************************************************************/
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		title    string
+		expected []string
+		elements []string
+	}{
+		{
+			name:     "URL set",
+			body:     urlsetXML,
+			expected: []string{"https://koroutine.tech/", "https://koroutine.tech/orphan"},
+			elements: []string{"url", "url"},
+		},
+		{
+			name:     "Sitemap index",
+			body:     sitemapIndexXML,
+			expected: []string{"https://koroutine.tech/sitemap-pages.xml", "https://koroutine.tech/sitemap-posts.xml.gz"},
+			elements: []string{"sitemap", "sitemap"},
+		},
+		{
+			name:     "Atom feed with relative links",
+			body:     atomXML,
+			title:    "Koroutine Atom",
+			expected: []string{"https://koroutine.tech/atom/one", "https://koroutine.tech/atom/two"},
+			elements: []string{"item", "item"},
+		},
+	}
+
+	for _, tc := range tests {
+		doc, err := DocumentFromXML(strings.NewReader(tc.body), "https://koroutine.tech/feed.xml")
+
+		if err != nil {
+			t.Fatalf("Test %s failed: unexpected error %v", tc.name, err)
+		}
+
+		var urls, elements []string
+
+		for _, link := range doc.Links {
+			urls = append(urls, link.URL)
+			elements = append(elements, link.Element)
+		}
+
+		if doc.Title != tc.title {
+			t.Errorf("Test %s failed: expected title %q, got %q", tc.name, tc.title, doc.Title)
+		}
+
+		if !reflect.DeepEqual(urls, tc.expected) || !reflect.DeepEqual(elements, tc.elements) {
+			t.Errorf("Test %s failed: expected %v %v, got %v %v", tc.name, tc.expected, tc.elements, urls, elements)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromXMLUnsupportedRoot(t *testing.T) {
+	_, err := DocumentFromXML(strings.NewReader(`<?xml version="1.0"?><svg></svg>`), "https://koroutine.tech/")
+
+	if err == nil || !strings.Contains(err.Error(), "<svg>") {
+		t.Errorf("Expected an unsupported root element error, got %v", err)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDocumentFromXMLEntries(t *testing.T) {
+	doc, err := DocumentFromXML(strings.NewReader(atomXML), "https://koroutine.tech/feed.xml")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(doc.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(doc.Entries))
+	}
+
+	entry := doc.Entries[0]
+
+	if entry.URL != "https://koroutine.tech/atom/one" || entry.Title != "Entry one" || entry.Published.IsZero() {
+		t.Errorf("Expected a resolved entry with a title and date, got %+v", entry)
+	}
+
+	// Sitemaps have no entries:
+	doc, err = DocumentFromXML(strings.NewReader(urlsetXML), "https://koroutine.tech/sitemap.xml")
+
+	if err != nil || doc.Entries != nil {
+		t.Errorf("Expected no entries for a sitemap, got %v, %v", doc.Entries, err)
+	}
+}
+
+/*****************************************************************************************************************/
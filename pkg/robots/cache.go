@@ -0,0 +1,133 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package robots
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+/*****************************************************************************************************************/
+
+// maxRobotsSize is the maximum number of bytes read from a robots.txt file, as recommended by RFC 9309.
+const maxRobotsSize = 500 * 1024
+
+/*****************************************************************************************************************/
+
+// Doer sends HTTP requests, e.g., an *http.Client, or a client which waits for a rate limiter first.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+/*****************************************************************************************************************/
+
+// entry is a cached robots.txt, with ready closed once it has been fetched.
+type entry struct {
+	ready  chan struct{}
+	robots *Robots
+}
+
+/*****************************************************************************************************************/
+
+// Cache fetches the robots.txt of each host once, and shares the result between concurrent callers.
+type Cache struct {
+	client    Doer
+	userAgent string
+	mu        sync.Mutex
+	entries   map[string]*entry
+}
+
+/*****************************************************************************************************************/
+
+// NewCache creates an empty Cache fetching robots.txt files with the given client and User-Agent.
+func NewCache(client Doer, userAgent string) *Cache {
+	return &Cache{
+		client:    client,
+		userAgent: userAgent,
+		entries:   make(map[string]*entry),
+	}
+}
+
+/*****************************************************************************************************************/
+
+// Get returns the robots.txt rules of the host of a URL, fetching them on first use.
+func (c *Cache) Get(ctx context.Context, u *url.URL) *Robots {
+	key := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+
+	e, ok := c.entries[key]
+
+	if !ok {
+		e = &entry{ready: make(chan struct{})}
+		c.entries[key] = e
+	}
+
+	c.mu.Unlock()
+
+	if !ok {
+		e.robots = c.fetch(ctx, key+"/robots.txt")
+		close(e.ready)
+	}
+
+	select {
+	case <-e.ready:
+		return e.robots
+	case <-ctx.Done():
+		return AllowAll()
+	}
+}
+
+/*****************************************************************************************************************/
+
+// fetch retrieves and parses a robots.txt file. Following RFC 9309, a missing file (4xx) allows everything, while
+// an unreachable one, i.e., a server error (5xx) or a network error such as a timeout, disallows everything. Only a
+// fetch abandoned because the context is done allows everything, as nothing more will be crawled.
+func (c *Cache) fetch(ctx context.Context, robotsURL string) *Robots {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+
+	if err != nil {
+		return AllowAll()
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil && ctx.Err() != nil {
+		return AllowAll()
+	}
+
+	if err != nil {
+		return DisallowAll()
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return DisallowAll()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return AllowAll()
+	}
+
+	robots, err := Parse(io.LimitReader(resp.Body, maxRobotsSize))
+
+	if err != nil {
+		return AllowAll()
+	}
+
+	return robots
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,130 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package robots
+
+/*****************************************************************************************************************/
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+func TestCacheFetchesOncePerHost(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.Header.Get("User-Agent") != "koroutine-web-crawler/1.0" {
+			t.Errorf("Expected the User-Agent to be sent, got %q", r.Header.Get("User-Agent"))
+		}
+
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+	}))
+
+	defer server.Close()
+
+	cache := NewCache(server.Client(), "koroutine-web-crawler/1.0")
+
+	u, _ := url.Parse(server.URL + "/page")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if cache.Get(context.Background(), u).Allowed("koroutine-web-crawler", "/private/page") {
+				t.Error("Expected /private/ to be disallowed")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected robots.txt to be fetched once, got %d", got)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCacheStatusCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		expected bool
+	}{
+		{
+			name:     "Missing robots.txt allows everything",
+			status:   http.StatusNotFound,
+			expected: true,
+		},
+		{
+			name:     "Server error disallows everything",
+			status:   http.StatusServiceUnavailable,
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			}))
+
+			defer server.Close()
+
+			u, _ := url.Parse(server.URL)
+
+			robots := NewCache(server.Client(), "koroutine-web-crawler").Get(context.Background(), u)
+
+			if got := robots.Allowed("koroutine-web-crawler", "/page"); got != tc.expected {
+				t.Errorf("Test %s failed: expected %v, got %v", tc.name, tc.expected, got)
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCacheUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	client := server.Client()
+
+	u, _ := url.Parse(server.URL)
+
+	// Nothing is listening once the server is closed, so the connection is refused:
+	server.Close()
+
+	if NewCache(client, "koroutine-web-crawler").Get(context.Background(), u).Allowed("koroutine-web-crawler", "/page") {
+		t.Error("Expected an unreachable robots.txt to disallow everything")
+	}
+
+	// A fetch abandoned because the crawl is over says nothing about the host:
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if !NewCache(client, "koroutine-web-crawler").Get(ctx, u).Allowed("koroutine-web-crawler", "/page") {
+		t.Error("Expected a cancelled fetch to allow everything")
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,120 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package robots
+
+/*****************************************************************************************************************/
+
+import (
+	"slices"
+	"strings"
+)
+
+/*****************************************************************************************************************/
+
+// Directives are the indexing directives of a page, given by <meta name="robots"> tags and X-Robots-Tag
+// response headers, e.g., "noindex, nofollow".
+type Directives struct {
+	NoIndex  bool
+	NoFollow bool
+	// Values are every directive in the order given, lowercased, e.g., "noarchive" or "max-snippet:50".
+	Values []string
+}
+
+/*****************************************************************************************************************/
+
+// valueDirectives are the directives which take a value after a colon, which must not be mistaken for the user
+// agent prefix of an X-Robots-Tag header, e.g., "unavailable_after: 2025-01-01" vs "googlebot: noindex".
+var valueDirectives = []string{"unavailable_after", "max-snippet", "max-image-preview", "max-video-preview"}
+
+/*****************************************************************************************************************/
+
+// ParseDirectives parses the comma-separated directives of a meta robots content attribute. "none" is
+// shorthand for "noindex, nofollow", and "all" (the default) has no effect.
+func ParseDirectives(content string) Directives {
+	var d Directives
+
+	for _, value := range strings.Split(content, ",") {
+		d.add(value)
+	}
+
+	return d
+}
+
+/*****************************************************************************************************************/
+
+// ParseHeader parses the values of X-Robots-Tag headers, applying values without a user agent prefix and values
+// prefixed with the product token of the given User-Agent, e.g., "koroutine-web-crawler: nofollow".
+func ParseHeader(values []string, userAgent string) Directives {
+	var d Directives
+
+	agent := Agent(userAgent)
+
+	for _, value := range values {
+		if prefix, rest, ok := strings.Cut(value, ":"); ok {
+			prefix = strings.ToLower(strings.TrimSpace(prefix))
+
+			// A prefix which is neither a directive nor one of ours is aimed at another crawler:
+			if !slices.Contains(valueDirectives, prefix) && !strings.Contains(prefix, ",") {
+				if prefix != agent {
+					continue
+				}
+
+				value = rest
+			}
+		}
+
+		d = d.Merge(ParseDirectives(value))
+	}
+
+	return d
+}
+
+/*****************************************************************************************************************/
+
+// Merge combines two sets of directives, e.g., those of the meta tags and the response headers of a page. The
+// most restrictive directive wins.
+func (d Directives) Merge(other Directives) Directives {
+	values := slices.Clone(d.Values)
+
+	for _, value := range other.Values {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	return Directives{
+		NoIndex:  d.NoIndex || other.NoIndex,
+		NoFollow: d.NoFollow || other.NoFollow,
+		Values:   values,
+	}
+}
+
+/*****************************************************************************************************************/
+
+// add adds a single directive.
+func (d *Directives) add(value string) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if value == "" {
+		return
+	}
+
+	switch value {
+	case "noindex":
+		d.NoIndex = true
+	case "nofollow":
+		d.NoFollow = true
+	case "none":
+		d.NoIndex, d.NoFollow = true, true
+	}
+
+	if !slices.Contains(d.Values, value) {
+		d.Values = append(d.Values, value)
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,90 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package robots
+
+/*****************************************************************************************************************/
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected Directives
+	}{
+		{content: "", expected: Directives{}},
+		{content: "all", expected: Directives{Values: []string{"all"}}},
+		{content: "noindex", expected: Directives{NoIndex: true, Values: []string{"noindex"}}},
+		{content: "NoIndex, NOFOLLOW", expected: Directives{NoIndex: true, NoFollow: true, Values: []string{"noindex", "nofollow"}}},
+		{content: "none", expected: Directives{NoIndex: true, NoFollow: true, Values: []string{"none"}}},
+		{content: " noarchive ,, max-snippet:50", expected: Directives{Values: []string{"noarchive", "max-snippet:50"}}},
+	}
+
+	for _, tc := range tests {
+		d := ParseDirectives(tc.content)
+
+		if !reflect.DeepEqual(d, tc.expected) {
+			t.Errorf("Test %q failed: expected %+v, got %+v", tc.content, tc.expected, d)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestParseHeader(t *testing.T) {
+	userAgent := "koroutine-web-crawler/1.0"
+
+	tests := []struct {
+		name     string
+		values   []string
+		noIndex  bool
+		noFollow bool
+	}{
+		{name: "No headers", values: nil},
+		{name: "Unprefixed", values: []string{"noindex"}, noIndex: true},
+		{name: "Several headers", values: []string{"noindex", "nofollow"}, noIndex: true, noFollow: true},
+		{name: "Our agent", values: []string{"Koroutine-Web-Crawler: nofollow"}, noFollow: true},
+		{name: "Another agent", values: []string{"googlebot: noindex, nofollow"}},
+		{name: "Value directive", values: []string{"unavailable_after: 25 Jun 2010 15:00:00 PST"}},
+		{name: "Value directive after another", values: []string{"noindex, unavailable_after: 25 Jun 2010"}, noIndex: true},
+	}
+
+	for _, tc := range tests {
+		d := ParseHeader(tc.values, userAgent)
+
+		if d.NoIndex != tc.noIndex || d.NoFollow != tc.noFollow {
+			t.Errorf("Test %s failed: expected noindex %v and nofollow %v, got %+v", tc.name, tc.noIndex, tc.noFollow, d)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestDirectivesMerge(t *testing.T) {
+	meta := ParseDirectives("noindex, noarchive")
+
+	header := ParseHeader([]string{"nofollow, noarchive"}, "koroutine-web-crawler")
+
+	merged := meta.Merge(header)
+
+	expected := Directives{NoIndex: true, NoFollow: true, Values: []string{"noindex", "noarchive", "nofollow"}}
+
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, merged)
+	}
+
+	// Merging does not modify either side:
+	if len(meta.Values) != 2 || meta.NoFollow {
+		t.Errorf("Expected the meta directives to be unchanged, got %+v", meta)
+	}
+}
+
+/*****************************************************************************************************************/
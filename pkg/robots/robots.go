@@ -0,0 +1,301 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package robots
+
+/*****************************************************************************************************************/
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+// Rule is a single Allow or Disallow line of a group. The pattern may contain "*" wildcards and a trailing "$"
+// anchoring it to the end of the path.
+type Rule struct {
+	Allow   bool
+	Pattern string
+}
+
+/*****************************************************************************************************************/
+
+// Group is a set of rules applying to one or more user agents.
+type Group struct {
+	Agents     []string
+	Rules      []Rule
+	CrawlDelay time.Duration
+}
+
+/*****************************************************************************************************************/
+
+// Robots holds the parsed contents of a robots.txt file.
+type Robots struct {
+	Groups   []*Group
+	Sitemaps []string
+	// disallowAll is set for hosts whose robots.txt could not be retrieved because of a server error.
+	disallowAll bool
+}
+
+/*****************************************************************************************************************/
+
+// AllowAll returns a Robots which allows every path, used when a host has no robots.txt.
+func AllowAll() *Robots {
+	return &Robots{}
+}
+
+/*****************************************************************************************************************/
+
+// DisallowAll returns a Robots which disallows every path, used when a host's robots.txt is unavailable.
+func DisallowAll() *Robots {
+	return &Robots{disallowAll: true}
+}
+
+/*****************************************************************************************************************/
+
+// Parse reads a robots.txt file. Unknown and malformed lines are ignored, as the format is best-effort.
+func Parse(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+
+	var current *Group
+
+	// Whether the current group has seen any rules yet, i.e., a new User-agent line starts a new group:
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Strip comments and surrounding whitespace:
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+
+		if !found {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &Group{}
+				robots.Groups = append(robots.Groups, current)
+				inRules = false
+			}
+
+			current.Agents = append(current.Agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+
+			inRules = true
+
+			// An empty Disallow line means nothing is disallowed:
+			if value == "" {
+				continue
+			}
+
+			current.Rules = append(current.Rules, Rule{Allow: key == "allow", Pattern: value})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+
+			inRules = true
+
+			seconds, err := strconv.ParseFloat(value, 64)
+
+			if err != nil || seconds < 0 {
+				continue
+			}
+
+			current.CrawlDelay = time.Duration(seconds * float64(time.Second))
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+	}
+
+	return robots, scanner.Err()
+}
+
+/*****************************************************************************************************************/
+
+// Agent returns the product token of a User-Agent header, e.g., "koroutine-web-crawler" for
+// "koroutine-web-crawler/1.0 (+https://koroutine.tech)".
+func Agent(userAgent string) string {
+	token := strings.TrimSpace(userAgent)
+
+	if i := strings.IndexAny(token, "/ "); i != -1 {
+		token = token[:i]
+	}
+
+	return strings.ToLower(token)
+}
+
+/*****************************************************************************************************************/
+
+// Group returns the rules applying to a user agent: all groups naming its product token merged together, or
+// the "*" groups when none do. It returns nil when no group applies.
+func (r *Robots) Group(userAgent string) *Group {
+	agent := Agent(userAgent)
+
+	var matched, wildcard *Group
+
+	for _, group := range r.Groups {
+		if group.names(agent) {
+			matched = merge(matched, group)
+		} else if group.names("*") {
+			wildcard = merge(wildcard, group)
+		}
+	}
+
+	if matched != nil {
+		return matched
+	}
+
+	return wildcard
+}
+
+/*****************************************************************************************************************/
+
+// Allowed checks if a user agent may fetch a path (including any query string).
+func (r *Robots) Allowed(userAgent string, path string) bool {
+	if r.disallowAll {
+		return false
+	}
+
+	// The robots.txt file itself is always allowed:
+	if path == "/robots.txt" {
+		return true
+	}
+
+	group := r.Group(userAgent)
+
+	if group == nil {
+		return true
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	// The most specific (longest) matching rule wins, with Allow winning ties:
+	allowed, longest := true, -1
+
+	for _, rule := range group.Rules {
+		if !match(rule.Pattern, path) {
+			continue
+		}
+
+		length := len(rule.Pattern)
+
+		if length > longest || (length == longest && rule.Allow) {
+			allowed, longest = rule.Allow, length
+		}
+	}
+
+	return allowed
+}
+
+/*****************************************************************************************************************/
+
+// CrawlDelay returns the Crawl-delay requested for a user agent, or zero when none is set.
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	group := r.Group(userAgent)
+
+	if group == nil {
+		return 0
+	}
+
+	return group.CrawlDelay
+}
+
+/*****************************************************************************************************************/
+
+// names checks if the group applies to an agent.
+func (g *Group) names(agent string) bool {
+	for _, a := range g.Agents {
+		if a == agent {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*****************************************************************************************************************/
+
+// merge combines the rules of two groups for the same agent.
+func merge(into *Group, group *Group) *Group {
+	if into == nil {
+		return &Group{
+			Agents:     group.Agents,
+			Rules:      append([]Rule(nil), group.Rules...),
+			CrawlDelay: group.CrawlDelay,
+		}
+	}
+
+	into.Rules = append(into.Rules, group.Rules...)
+
+	if group.CrawlDelay > into.CrawlDelay {
+		into.CrawlDelay = group.CrawlDelay
+	}
+
+	return into
+}
+
+/*****************************************************************************************************************/
+
+// match checks if a path matches a rule pattern, where "*" matches any sequence of characters and a trailing
+// "$" requires the pattern to match the whole path. Patterns otherwise match path prefixes.
+func match(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	// The first part has to match at the start of the path:
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	rest := path[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+		// An anchored final part has to match at the very end of the path:
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+
+		j := strings.Index(rest, part)
+
+		if j == -1 {
+			return false
+		}
+
+		rest = rest[j+len(part):]
+	}
+
+	return !anchored || rest == ""
+}
+
+/*****************************************************************************************************************/
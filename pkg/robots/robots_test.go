@@ -0,0 +1,190 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package robots
+
+/*****************************************************************************************************************/
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+/*****************************************************************************************************************/
+
+const robotsTxt = `
+# Example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/public/
+Disallow: /*.pdf$
+Disallow: /search?
+
+User-agent: koroutine-web-crawler
+User-agent: otherbot
+Disallow: /admin
+Crawl-delay: 1.5
+
+User-agent: koroutine-web-crawler
+Disallow: /tmp/ # merged with the group above
+
+Sitemap: https://koroutine.tech/sitemap.xml
+`
+
+/*****************************************************************************************************************/
+
+func TestParse(t *testing.T) {
+	robots, err := Parse(strings.NewReader(robotsTxt))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(robots.Groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %d", len(robots.Groups))
+	}
+
+	if got := robots.Groups[1].Agents; len(got) != 2 || got[0] != "koroutine-web-crawler" || got[1] != "otherbot" {
+		t.Errorf("Expected both agents in the second group, got %v", got)
+	}
+
+	if len(robots.Sitemaps) != 1 || robots.Sitemaps[0] != "https://koroutine.tech/sitemap.xml" {
+		t.Errorf("Expected the sitemap to be parsed, got %v", robots.Sitemaps)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestAllowed(t *testing.T) {
+	robots, _ := Parse(strings.NewReader(robotsTxt))
+
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		expected  bool
+	}{
+		{
+			name:      "Wildcard group disallow",
+			userAgent: "somebot/2.0",
+			path:      "/private/page",
+			expected:  false,
+		},
+		{
+			name:      "Longer allow wins",
+			userAgent: "somebot/2.0",
+			path:      "/private/public/page",
+			expected:  true,
+		},
+		{
+			name:      "End anchored wildcard",
+			userAgent: "somebot/2.0",
+			path:      "/files/report.pdf",
+			expected:  false,
+		},
+		{
+			name:      "End anchor does not match longer path",
+			userAgent: "somebot/2.0",
+			path:      "/files/report.pdf.html",
+			expected:  true,
+		},
+		{
+			name:      "Query string",
+			userAgent: "somebot/2.0",
+			path:      "/search?q=go",
+			expected:  false,
+		},
+		{
+			name:      "Specific group replaces wildcard group",
+			userAgent: "koroutine-web-crawler/1.0",
+			path:      "/private/page",
+			expected:  true,
+		},
+		{
+			name:      "Specific group disallow",
+			userAgent: "Koroutine-Web-Crawler/1.0",
+			path:      "/admin/users",
+			expected:  false,
+		},
+		{
+			name:      "Merged groups",
+			userAgent: "koroutine-web-crawler/1.0",
+			path:      "/tmp/file",
+			expected:  false,
+		},
+		{
+			name:      "Robots file always allowed",
+			userAgent: "koroutine-web-crawler/1.0",
+			path:      "/robots.txt",
+			expected:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := robots.Allowed(tc.userAgent, tc.path); got != tc.expected {
+				t.Errorf("Test %s failed: expected %v, got %v", tc.name, tc.expected, got)
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCrawlDelay(t *testing.T) {
+	robots, _ := Parse(strings.NewReader(robotsTxt))
+
+	if got := robots.CrawlDelay("koroutine-web-crawler/1.0"); got != 1500*time.Millisecond {
+		t.Errorf("Expected a crawl delay of 1.5s, got %v", got)
+	}
+
+	if got := robots.CrawlDelay("somebot"); got != 0 {
+		t.Errorf("Expected no crawl delay, got %v", got)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestAllowAllAndDisallowAll(t *testing.T) {
+	if !AllowAll().Allowed("koroutine-web-crawler", "/anything") {
+		t.Error("Expected AllowAll to allow every path")
+	}
+
+	if DisallowAll().Allowed("koroutine-web-crawler", "/anything") {
+		t.Error("Expected DisallowAll to disallow every path")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"/", "/anything", true},
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish.html", false},
+		{"/fish*", "/fishheads/yummy.html", true},
+		{"/*.php", "/folder/filename.php?parameters", true},
+		{"/*.php$", "/filename.php", true},
+		{"/*.php$", "/filename.php?parameters", false},
+		{"/fish*.php", "/fishheads/catfish.php?parameters", true},
+		{"/fish*.php", "/Fish.PHP", false},
+		{"/exact$", "/exact", true},
+		{"/exact$", "/exactly", false},
+	}
+
+	for _, tc := range tests {
+		if got := match(tc.pattern, tc.path); got != tc.expected {
+			t.Errorf("match(%q, %q): expected %v, got %v", tc.pattern, tc.path, tc.expected, got)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
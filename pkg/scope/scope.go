@@ -0,0 +1,283 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package scope
+
+/*****************************************************************************************************************/
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+/*****************************************************************************************************************/
+
+// Reasons reported in Decision.Reason for URLs which are out of scope.
+const (
+	ReasonScheme   = "scheme"
+	ReasonExcluded = "excluded"
+	ReasonHost     = "host"
+	ReasonPath     = "path"
+	ReasonIncluded = "not included"
+)
+
+/*****************************************************************************************************************/
+
+// Rules configures which URLs are in scope of a crawl. The zero value only allows the start host.
+//
+// Rules are evaluated in a fixed order, and the first rule to reject a URL decides:
+//
+//  1. The scheme must be http or https.
+//  2. The URL must not match any Exclude pattern.
+//  3. The host must be the start host, one of AllowedHosts, a subdomain of either when IncludeSubdomains is
+//     set, or share the start host's registrable domain when SameSite is set.
+//  4. When PathPrefixes are set, the path must be within one of them.
+//  5. When Include patterns are set, the URL must match one of them.
+//
+// Patterns are globs matching the whole canonical URL, where "*" matches any run of characters and "?" any single
+// character, unless prefixed with "re:", in which case the rest is a regular expression matching anywhere in the
+// canonical URL, like regexp.MatchString, unless it is anchored with "^" and "$".
+type Rules struct {
+	AllowedHosts      []string `json:"allowed_hosts,omitempty"`
+	IncludeSubdomains bool     `json:"include_subdomains,omitempty"`
+	SameSite          bool     `json:"same_site,omitempty"`
+	PathPrefixes      []string `json:"path_prefixes,omitempty"`
+	Include           []string `json:"include,omitempty"`
+	Exclude           []string `json:"exclude,omitempty"`
+}
+
+/*****************************************************************************************************************/
+
+// Validate reports whether every rule is usable, e.g., that all patterns compile.
+func (r Rules) Validate() error {
+	for _, host := range r.AllowedHosts {
+		if strings.TrimSpace(host) == "" {
+			return errors.New("allowed hosts must not be empty")
+		}
+	}
+
+	for _, prefix := range r.PathPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("path prefix %q must start with /", prefix)
+		}
+	}
+
+	if _, err := compile(r.Include); err != nil {
+		return err
+	}
+
+	if _, err := compile(r.Exclude); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/*****************************************************************************************************************/
+
+// Decision is the outcome of checking a URL against a Scope.
+type Decision struct {
+	In     bool
+	Reason string // the rule which rejected the URL, empty when it is in scope
+}
+
+/*****************************************************************************************************************/
+
+// Scope decides whether URLs are in scope of a crawl starting from a given URL.
+type Scope struct {
+	rules   Rules
+	hosts   []string // the start host and allowed hosts, lowercased and without ports
+	site    string   // the registrable domain of the start host, e.g., "example.co.uk"
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+/*****************************************************************************************************************/
+
+// New creates a Scope for a crawl starting from a given URL.
+func New(start *url.URL, rules Rules) (*Scope, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	include, _ := compile(rules.Include)
+
+	exclude, _ := compile(rules.Exclude)
+
+	startHost := strings.ToLower(start.Hostname())
+
+	s := &Scope{
+		rules:   rules,
+		hosts:   []string{startHost},
+		include: include,
+		exclude: exclude,
+	}
+
+	for _, host := range rules.AllowedHosts {
+		s.hosts = append(s.hosts, strings.ToLower(strings.TrimSpace(host)))
+	}
+
+	// IP addresses and hosts without a public suffix have no registrable domain, so only the host itself:
+	if site, err := publicsuffix.EffectiveTLDPlusOne(startHost); err == nil {
+		s.site = site
+	} else {
+		s.site = startHost
+	}
+
+	return s, nil
+}
+
+/*****************************************************************************************************************/
+
+// Contains checks if a URL is in scope.
+func (s *Scope) Contains(u *url.URL) bool {
+	return s.Check(u).In
+}
+
+/*****************************************************************************************************************/
+
+// Check evaluates the rules against a URL, in the order documented on Rules.
+func (s *Scope) Check(u *url.URL) Decision {
+	scheme := strings.ToLower(u.Scheme)
+
+	if scheme != "http" && scheme != "https" {
+		return Decision{Reason: ReasonScheme}
+	}
+
+	full := u.String()
+
+	if matchAny(s.exclude, full) {
+		return Decision{Reason: ReasonExcluded}
+	}
+
+	if !s.hostAllowed(strings.ToLower(u.Hostname())) {
+		return Decision{Reason: ReasonHost}
+	}
+
+	if len(s.rules.PathPrefixes) > 0 && !s.pathAllowed(u.EscapedPath()) {
+		return Decision{Reason: ReasonPath}
+	}
+
+	if len(s.include) > 0 && !matchAny(s.include, full) {
+		return Decision{Reason: ReasonIncluded}
+	}
+
+	return Decision{In: true}
+}
+
+/*****************************************************************************************************************/
+
+// hostAllowed checks a lowercased host against the host rules.
+func (s *Scope) hostAllowed(host string) bool {
+	for _, allowed := range s.hosts {
+		if host == allowed {
+			return true
+		}
+
+		if s.rules.IncludeSubdomains && strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+
+	if s.rules.SameSite && (host == s.site || strings.HasSuffix(host, "."+s.site)) {
+		return true
+	}
+
+	return false
+}
+
+/*****************************************************************************************************************/
+
+// pathAllowed checks a path against the path prefixes. Prefixes match whole segments with or without a trailing
+// slash, so "/docs/" matches "/docs", "/docs/" and "/docs/intro" but not "/docsearch".
+func (s *Scope) pathAllowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	for _, prefix := range s.rules.PathPrefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*****************************************************************************************************************/
+
+// compile turns glob and "re:" patterns into anchored regular expressions.
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+
+	for _, pattern := range patterns {
+		var expr string
+
+		// Regular expressions are used as written, so they are only anchored when they say so:
+		if re, ok := strings.CutPrefix(pattern, "re:"); ok {
+			expr = re
+		} else {
+			expr = globToRegexp(pattern)
+		}
+
+		re, err := regexp.Compile(expr)
+
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+/*****************************************************************************************************************/
+
+// globToRegexp converts a glob, where "*" matches any run of characters and "?" any single character, to an
+// anchored regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
+
+/*****************************************************************************************************************/
+
+// matchAny checks if a string matches any of the expressions.
+func matchAny(expressions []*regexp.Regexp, s string) bool {
+	for _, re := range expressions {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,260 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package scope
+
+/*****************************************************************************************************************/
+
+import (
+	"net/url"
+	"testing"
+)
+
+/*****************************************************************************************************************/
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", raw, err)
+	}
+
+	return u
+}
+
+/*****************************************************************************************************************/
+
+func TestScopeCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  string
+		rules  Rules
+		url    string
+		reason string // empty when the URL is expected to be in scope
+	}{
+		{
+			name:  "Same host",
+			start: "https://koroutine.tech/",
+			url:   "https://koroutine.tech/about",
+		},
+		{
+			name:  "Scheme variant of the start host",
+			start: "http://koroutine.tech/",
+			url:   "https://koroutine.tech/about",
+		},
+		{
+			name:   "Unsupported scheme",
+			start:  "https://koroutine.tech/",
+			url:    "ftp://koroutine.tech/file",
+			reason: ReasonScheme,
+		},
+		{
+			name:   "External host",
+			start:  "https://koroutine.tech/",
+			url:    "https://external.com/",
+			reason: ReasonHost,
+		},
+		{
+			name:   "Subdomain without IncludeSubdomains",
+			start:  "https://koroutine.tech/",
+			url:    "https://blog.koroutine.tech/",
+			reason: ReasonHost,
+		},
+		{
+			name:  "Subdomain with IncludeSubdomains",
+			start: "https://koroutine.tech/",
+			rules: Rules{IncludeSubdomains: true},
+			url:   "https://blog.koroutine.tech/",
+		},
+		{
+			name:   "Suffix which is not a subdomain",
+			start:  "https://koroutine.tech/",
+			rules:  Rules{IncludeSubdomains: true},
+			url:    "https://notkoroutine.tech/",
+			reason: ReasonHost,
+		},
+		{
+			name:   "Parent domain with IncludeSubdomains",
+			start:  "https://www.koroutine.tech/",
+			rules:  Rules{IncludeSubdomains: true},
+			url:    "https://koroutine.tech/",
+			reason: ReasonHost,
+		},
+		{
+			name:  "Parent domain with SameSite",
+			start: "https://www.koroutine.tech/",
+			rules: Rules{SameSite: true},
+			url:   "https://koroutine.tech/",
+		},
+		{
+			name:  "Sibling subdomain with SameSite",
+			start: "https://www.example.co.uk/",
+			rules: Rules{SameSite: true},
+			url:   "https://shop.example.co.uk/",
+		},
+		{
+			name:   "Other registrable domain under the same public suffix",
+			start:  "https://www.example.co.uk/",
+			rules:  Rules{SameSite: true},
+			url:    "https://other.co.uk/",
+			reason: ReasonHost,
+		},
+		{
+			name:  "Allowed host",
+			start: "https://koroutine.tech/",
+			rules: Rules{AllowedHosts: []string{"Docs.Koroutine.dev"}},
+			url:   "https://docs.koroutine.dev/",
+		},
+		{
+			name:  "Subdomain of an allowed host",
+			start: "https://koroutine.tech/",
+			rules: Rules{AllowedHosts: []string{"koroutine.dev"}, IncludeSubdomains: true},
+			url:   "https://docs.koroutine.dev/",
+		},
+		{
+			name:  "Path prefix",
+			start: "https://koroutine.tech/blog/",
+			rules: Rules{PathPrefixes: []string{"/blog/"}},
+			url:   "https://koroutine.tech/blog/post",
+		},
+		{
+			name:   "Outside the path prefix",
+			start:  "https://koroutine.tech/blog/",
+			rules:  Rules{PathPrefixes: []string{"/blog/"}},
+			url:    "https://koroutine.tech/about",
+			reason: ReasonPath,
+		},
+		{
+			name:  "Path prefix without its trailing slash",
+			start: "https://koroutine.tech/blog/",
+			rules: Rules{PathPrefixes: []string{"/blog/"}},
+			url:   "https://koroutine.tech/blog",
+		},
+		{
+			name:   "Path sharing a prefix but not a segment",
+			start:  "https://koroutine.tech/blog/",
+			rules:  Rules{PathPrefixes: []string{"/blog"}},
+			url:    "https://koroutine.tech/blogroll",
+			reason: ReasonPath,
+		},
+		{
+			name:  "Glob include",
+			start: "https://koroutine.tech/",
+			rules: Rules{Include: []string{"https://koroutine.tech/docs/*"}},
+			url:   "https://koroutine.tech/docs/intro",
+		},
+		{
+			name:   "Not matching any include",
+			start:  "https://koroutine.tech/",
+			rules:  Rules{Include: []string{"https://koroutine.tech/docs/*"}},
+			url:    "https://koroutine.tech/about",
+			reason: ReasonIncluded,
+		},
+		{
+			name:   "Regular expression exclude",
+			start:  "https://koroutine.tech/",
+			rules:  Rules{Exclude: []string{`re:\.(pdf|zip)$`}},
+			url:    "https://koroutine.tech/files/report.pdf",
+			reason: ReasonExcluded,
+		},
+		{
+			name:   "Regular expression matches anywhere in the URL",
+			start:  "https://koroutine.tech/",
+			rules:  Rules{Exclude: []string{`re:/admin`}},
+			url:    "https://koroutine.tech/search?next=/admin",
+			reason: ReasonExcluded,
+		},
+		{
+			name:  "Anchored regular expression matches the whole URL",
+			start: "https://koroutine.tech/",
+			rules: Rules{Exclude: []string{`re:^https://koroutine\.tech/admin`}},
+			url:   "https://koroutine.tech/search?next=/admin",
+		},
+		{
+			name:   "Exclude wins over include",
+			start:  "https://koroutine.tech/",
+			rules:  Rules{Include: []string{"*/docs/*"}, Exclude: []string{"*/docs/private/*"}},
+			url:    "https://koroutine.tech/docs/private/keys",
+			reason: ReasonExcluded,
+		},
+		{
+			name:   "Exclude is evaluated before the host",
+			start:  "https://koroutine.tech/",
+			rules:  Rules{Exclude: []string{"https://external.com/*"}},
+			url:    "https://external.com/",
+			reason: ReasonExcluded,
+		},
+		{
+			name:  "Glob question mark",
+			start: "https://koroutine.tech/",
+			rules: Rules{Include: []string{"*/page?"}},
+			url:   "https://koroutine.tech/page2",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(mustParse(t, tc.start), tc.rules)
+
+			if err != nil {
+				t.Fatalf("Test %s failed: unexpected error %v", tc.name, err)
+			}
+
+			decision := s.Check(mustParse(t, tc.url))
+
+			if decision.In != (tc.reason == "") || decision.Reason != tc.reason {
+				t.Errorf("Test %s failed: expected reason %q, got %+v", tc.name, tc.reason, decision)
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestScopeSameSiteIPAddress(t *testing.T) {
+	s, err := New(mustParse(t, "http://127.0.0.1:8080/"), Rules{SameSite: true})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !s.Contains(mustParse(t, "http://127.0.0.1:8080/about")) {
+		t.Errorf("Expected the start host to be in scope")
+	}
+
+	if s.Contains(mustParse(t, "http://127.0.0.2/")) {
+		t.Errorf("Expected a different IP address to be out of scope")
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestRulesValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		valid bool
+	}{
+		{name: "Zero value", rules: Rules{}, valid: true},
+		{name: "Glob patterns", rules: Rules{Include: []string{"*/docs/*"}, Exclude: []string{"*.pdf"}}, valid: true},
+		{name: "Empty allowed host", rules: Rules{AllowedHosts: []string{" "}}},
+		{name: "Relative path prefix", rules: Rules{PathPrefixes: []string{"blog/"}}},
+		{name: "Invalid include expression", rules: Rules{Include: []string{"re:("}}},
+		{name: "Invalid exclude expression", rules: Rules{Exclude: []string{"re:[a-"}}},
+	}
+
+	for _, tc := range tests {
+		err := tc.rules.Validate()
+
+		if (err == nil) != tc.valid {
+			t.Errorf("Test %s failed: expected valid %v, got error %v", tc.name, tc.valid, err)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,293 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package validate
+
+/*****************************************************************************************************************/
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+/*****************************************************************************************************************/
+
+// CanonicalOptions selects the normalisations applied by Canonicalize.
+type CanonicalOptions struct {
+	// LowercaseHost lowercases the host (the scheme is always lowercased).
+	LowercaseHost bool
+	// RemoveDefaultPort removes :80 from http and :443 from https URLs.
+	RemoveDefaultPort bool
+	// StripFragment removes the #fragment.
+	StripFragment bool
+	// RemoveDotSegments resolves "." and ".." path segments.
+	RemoveDotSegments bool
+	// NormalisePercentEncoding decodes needlessly escaped unreserved characters and uppercases escapes.
+	NormalisePercentEncoding bool
+	// SortQuery sorts the query parameters by name, then value.
+	SortQuery bool
+	// RemoveTrailingSlash removes a trailing slash from any path other than the root.
+	RemoveTrailingSlash bool
+	// StripParams are glob patterns of query parameter names to remove, e.g., "utm_*".
+	StripParams []string
+}
+
+/*****************************************************************************************************************/
+
+// DefaultCanonicalOptions applies every normalisation, and strips common tracking parameters.
+var DefaultCanonicalOptions = CanonicalOptions{
+	LowercaseHost:            true,
+	RemoveDefaultPort:        true,
+	StripFragment:            true,
+	RemoveDotSegments:        true,
+	NormalisePercentEncoding: true,
+	SortQuery:                true,
+	RemoveTrailingSlash:      true,
+	StripParams:              []string{"utm_*", "gclid", "fbclid", "msclkid", "mc_cid", "mc_eid", "_ga"},
+}
+
+/*****************************************************************************************************************/
+
+// defaultPorts are the ports implied by each scheme.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+/*****************************************************************************************************************/
+
+// Canonicalize normalises a URL, so that different spellings of the same resource compare equal.
+func Canonicalize(raw string, opts CanonicalOptions) (string, error) {
+	uri, err := url.Parse(strings.TrimSpace(raw))
+
+	// If there's an error parsing the URL, return it:
+	if err != nil {
+		return "", err
+	}
+
+	return CanonicalizeURL(uri, opts).String(), nil
+}
+
+/*****************************************************************************************************************/
+
+// CanonicalizeURL normalises a parsed URL, returning a new URL and leaving the original untouched.
+func CanonicalizeURL(uri *url.URL, opts CanonicalOptions) *url.URL {
+	u := *uri
+
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	if opts.LowercaseHost {
+		u.Host = strings.ToLower(u.Host)
+	}
+
+	if opts.RemoveDefaultPort && u.Port() == defaultPorts[u.Scheme] {
+		u.Host = strings.TrimSuffix(u.Host, ":"+u.Port())
+	}
+
+	if opts.StripFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	// Work on the escaped path, so that escaped and unescaped slashes are kept apart:
+	escapedPath := u.EscapedPath()
+
+	if opts.NormalisePercentEncoding {
+		escapedPath = normalisePercentEncoding(escapedPath)
+	}
+
+	if opts.RemoveDotSegments {
+		escapedPath = removeDotSegments(escapedPath)
+	}
+
+	// An empty path of a URL with a host is equivalent to the root path:
+	if escapedPath == "" && u.Host != "" {
+		escapedPath = "/"
+	}
+
+	if opts.RemoveTrailingSlash && len(escapedPath) > 1 {
+		escapedPath = strings.TrimSuffix(escapedPath, "/")
+	}
+
+	if unescaped, err := url.PathUnescape(escapedPath); err == nil {
+		u.Path = unescaped
+		u.RawPath = escapedPath
+	}
+
+	u.RawQuery = canonicalQuery(u.RawQuery, opts)
+	u.ForceQuery = false
+
+	return &u
+}
+
+/*****************************************************************************************************************/
+
+// canonicalQuery strips the configured parameters from a raw query, optionally sorting the remaining ones.
+func canonicalQuery(rawQuery string, opts CanonicalOptions) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	var params []string
+
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(param, "=")
+
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+
+		if matchesAny(opts.StripParams, name) {
+			continue
+		}
+
+		if opts.NormalisePercentEncoding {
+			param = normalisePercentEncoding(param)
+		}
+
+		params = append(params, param)
+	}
+
+	if opts.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			ki, vi, _ := strings.Cut(params[i], "=")
+			kj, vj, _ := strings.Cut(params[j], "=")
+
+			if ki != kj {
+				return ki < kj
+			}
+
+			return vi < vj
+		})
+	}
+
+	return strings.Join(params, "&")
+}
+
+/*****************************************************************************************************************/
+
+// matchesAny checks if a name matches any of the glob patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*****************************************************************************************************************/
+
+// normalisePercentEncoding decodes percent-encoded unreserved characters (RFC 3986 section 2.3), which never
+// need escaping, and uppercases the hex digits of every remaining escape.
+func normalisePercentEncoding(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+
+		i += 2
+	}
+
+	return b.String()
+}
+
+/*****************************************************************************************************************/
+
+// removeDotSegments resolves "." and ".." segments of a path, following RFC 3986 section 5.2.4.
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+
+	segments := strings.Split(p, "/")
+
+	var output []string
+
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		switch segment {
+		case ".":
+			// A trailing dot segment still denotes a directory:
+			if last {
+				output = append(output, "")
+			}
+		case "..":
+			// Never remove the leading empty segment of an absolute path:
+			if len(output) > 1 || (len(output) == 1 && output[0] != "") {
+				output = output[:len(output)-1]
+			}
+
+			if last {
+				output = append(output, "")
+			}
+		default:
+			output = append(output, segment)
+		}
+	}
+
+	result := strings.Join(output, "/")
+
+	if strings.HasPrefix(p, "/") && !strings.HasPrefix(result, "/") {
+		result = "/" + result
+	}
+
+	return result
+}
+
+/*****************************************************************************************************************/
+
+// isUnreserved checks if a byte is an unreserved URI character.
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+/*****************************************************************************************************************/
+
+// isHex checks if a byte is a hexadecimal digit.
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+/*****************************************************************************************************************/
+
+// unhex returns the value of a hexadecimal digit.
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+/*****************************************************************************************************************/
@@ -0,0 +1,174 @@
+/*****************************************************************************************************************/
+
+//	@author		Michael Roberts
+
+/*****************************************************************************************************************/
+
+package validate
+
+/*****************************************************************************************************************/
+
+import "testing"
+
+/*****************************************************************************************************************/
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{
+			name:     "Already canonical",
+			raw:      "https://example.com/a",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "Empty path becomes root",
+			raw:      "https://example.com",
+			expected: "https://example.com/",
+		},
+		{
+			name:     "Lowercase scheme and host",
+			raw:      "HTTPS://Example.COM/Path",
+			expected: "https://example.com/Path",
+		},
+		{
+			name:     "Default https port",
+			raw:      "https://example.com:443/a",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "Default http port",
+			raw:      "http://example.com:80/a",
+			expected: "http://example.com/a",
+		},
+		{
+			name:     "Non-default port kept",
+			raw:      "https://example.com:8443/a",
+			expected: "https://example.com:8443/a",
+		},
+		{
+			name:     "Fragment stripped",
+			raw:      "https://example.com/a#top",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "Trailing slash removed",
+			raw:      "https://example.com/a/",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "Dot segments",
+			raw:      "https://example.com/a/./b/../c",
+			expected: "https://example.com/a/c",
+		},
+		{
+			name:     "Dot segments above root",
+			raw:      "https://example.com/../../a",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "Unreserved characters decoded",
+			raw:      "https://example.com/%7Euser/%61bc",
+			expected: "https://example.com/~user/abc",
+		},
+		{
+			name:     "Reserved escapes uppercased",
+			raw:      "https://example.com/a%2fb?q=a%2cb",
+			expected: "https://example.com/a%2Fb?q=a%2Cb",
+		},
+		{
+			name:     "Query sorted",
+			raw:      "https://example.com/a?b=1&a=2",
+			expected: "https://example.com/a?a=2&b=1",
+		},
+		{
+			name:     "Repeated parameters sorted by value",
+			raw:      "https://example.com/a?tag=z&tag=a",
+			expected: "https://example.com/a?tag=a&tag=z",
+		},
+		{
+			name:     "Tracking parameters stripped",
+			raw:      "https://example.com/a?utm_source=news&id=7&utm_medium=email&fbclid=xyz",
+			expected: "https://example.com/a?id=7",
+		},
+		{
+			name:     "Empty query removed",
+			raw:      "https://example.com/a?utm_source=news",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "Trimmed whitespace",
+			raw:      "  https://example.com/a  ",
+			expected: "https://example.com/a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			uri, err := Canonicalize(tc.raw, DefaultCanonicalOptions)
+
+			if err != nil {
+				t.Errorf("Test %s failed: %s", tc.name, err)
+			}
+
+			if uri != tc.expected {
+				t.Errorf("Test %s failed: expected %s, got %s", tc.name, tc.expected, uri)
+			}
+		})
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCanonicalizeEquivalentSpellings(t *testing.T) {
+	spellings := []string{
+		"https://site.com/a",
+		"https://site.com/a/",
+		"https://site.com/a#top",
+		"HTTPS://Site.com/a",
+		"https://site.com:443/a",
+		"https://site.com/b/../a",
+	}
+
+	for _, raw := range spellings {
+		uri, err := Canonicalize(raw, DefaultCanonicalOptions)
+
+		if err != nil || uri != "https://site.com/a" {
+			t.Errorf("Expected %s to canonicalize to https://site.com/a, got %s (%v)", raw, uri, err)
+		}
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCanonicalizeOptions(t *testing.T) {
+	// With no normalisations enabled, only the scheme is lowercased:
+	uri, err := Canonicalize("HTTPS://Example.com:443/a/?b=1&a=2#top", CanonicalOptions{})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if uri != "https://Example.com:443/a/?b=1&a=2#top" {
+		t.Errorf("Expected the URL to be left alone, got %s", uri)
+	}
+
+	// Custom parameters can be stripped without sorting:
+	uri, _ = Canonicalize("https://example.com/?sessionid=1&b=2&a=1", CanonicalOptions{StripParams: []string{"session*"}})
+
+	if uri != "https://example.com/?b=2&a=1" {
+		t.Errorf("Expected the session parameter to be stripped, got %s", uri)
+	}
+}
+
+/*****************************************************************************************************************/
+
+func TestCanonicalizeInvalid(t *testing.T) {
+	if _, err := Canonicalize("https://example.com/%zz", DefaultCanonicalOptions); err == nil {
+		t.Error("Expected an error for an invalid URL")
+	}
+}
+
+/*****************************************************************************************************************/